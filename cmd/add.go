@@ -21,7 +21,10 @@
 package cmd
 
 import (
+	"encoding/base64"
 	"fmt"
+	"io/ioutil"
+	"os"
 
 	"github.com/charles-haynes/transmission"
 	"github.com/spf13/cobra"
@@ -33,7 +36,8 @@ var addCmd = &cobra.Command{
 	Short: "Add a torrent",
 	Args:  cobra.ExactArgs(1),
 	Long: `Add adds a new torrent to the server.
-Argument can be a URL, magnet link, or file name.
+Argument can be a URL, magnet link, or file name. A file that exists here is
+checked and sent to the server, so the server doesn't need to see it.
 
 Example:
 <root> add "https://cdimage.debian.org/debian-cd/current/amd64/bt-dvd/debian-9.2.1-amd64-DVD-1.iso.torrent"`,
@@ -41,6 +45,10 @@ Example:
 }
 
 func doAdd(cmd *cobra.Command, args []string) {
+	if _, err := os.Stat(args[0]); err == nil {
+		addFile(args[0], nil)
+		return
+	}
 	x := getServer()
 	c := transmission.NewAddCmdByURL(args[0])
 	res, err := x.ExecuteCommand(c)
@@ -57,6 +65,35 @@ func doAdd(cmd *cobra.Command, args []string) {
 	}
 }
 
+// addFile checks the .torrent at path and adds it to the server, with any
+// further torrent-add arguments in args.
+func addFile(path string, args map[string]interface{}) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if _, err = parseMetainfo(data); err != nil {
+		fmt.Printf("%s: %s\n", path, err)
+		return
+	}
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	args["metainfo"] = base64.StdEncoding.EncodeToString(data)
+	t, dup, err := getRPC().torrentAdd(args)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if dup {
+		fmt.Println("duplicate")
+	} else {
+		fmt.Println("success")
+	}
+	fmt.Printf("%3d: %s %s\n", t.ID, t.HashString, t.Name)
+}
+
 func init() {
 	RootCmd.AddCommand(addCmd)
 
@@ -25,7 +25,6 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/charles-haynes/transmission"
 	"github.com/spf13/cobra"
 )
 
@@ -36,42 +35,68 @@ var cleanCmd = &cobra.Command{
 	Long: `Clean up unregistered torrents
 
 Remove any unregistered torrents that have the same name as a registered 
-torrent. Print a list of all other unregistered torrents. Takes list of torrent 
+torrent. Print a list of all other unregistered torrents, and the trr export
+command that saves their .torrent files to ~/uploadable. Takes list of torrent 
 specifiers, Defaults to all.`,
 	Run: doClean,
 }
 
+// unregistered is the error a tracker reports for a torrent it doesn't know.
+const unregistered = "Unregistered torrent"
+
+// registeredNames returns the names of the torrents in ts that aren't
+// unregistered.
+func registeredNames(ts []*rpcTorrent) map[string]bool {
+	r := map[string]bool{}
+	for _, t := range ts {
+		if t.ErrorString != unregistered {
+			r[t.Name] = true
+		}
+	}
+	return r
+}
+
+// unregisteredTwins returns the unregistered torrents in ts that have the same
+// name as a registered torrent. It picks the torrents of the built in clean
+// rule.
+func unregisteredTwins(ts []*rpcTorrent) []*rpcTorrent {
+	r := registeredNames(ts)
+	twins := []*rpcTorrent{}
+	for _, t := range ts {
+		if t.ErrorString == unregistered && r[t.Name] {
+			twins = append(twins, t)
+		}
+	}
+	return twins
+}
+
 func doClean(cmd *cobra.Command, args []string) {
-	x := getServer()
-	c := transmission.NewGetTorrentsCmd()
-	c.Arguments.Ids = getTorrents()
-	c.Arguments.Fields = []string{"errorString", "hashString", "id", "name", "status"}
-	res, err := x.ExecuteCommand(c)
+	x := getRPC()
+	ids, err := getTorrents()
 	if err != nil {
 		fmt.Println(err)
 		return
 	}
-	ts := res.Arguments.Torrents
-	r := map[string]interface{}{}
-	for _, t := range ts {
-		if t.ErrorString != "Unregistered torrent" {
-			r[t.Name] = nil
-		}
+	ts, err := x.torrentGet(ids, []string{"errorString", "id", "name", "status"})
+	if err != nil {
+		fmt.Println(err)
+		return
 	}
+	r := registeredNames(ts)
 	fmt.Printf("# %d registered torrents\n", len(r))
-	d := []string{}
+	d, u := []string{}, []string{}
 	for _, t := range ts {
-		if t.ErrorString == "Unregistered torrent" {
+		if t.ErrorString == unregistered {
 			d = append(d, strconv.Itoa(t.ID))
-			if _, ok := r[t.Name]; !ok {
-				l := fmt.Sprintf(
-					`cp -v "%s.%s.torrent" ~/uploadable`,
-					t.Name,
-					t.Hash[0:16])
-				fmt.Println(l)
+			if !r[t.Name] {
+				fmt.Printf("# %d %s\n", t.ID, t.Name)
+				u = append(u, strconv.Itoa(t.ID))
 			}
 		}
 	}
+	if len(u) > 0 {
+		fmt.Printf("trr --server %s export torrent -t %s ~/uploadable\n", server, strings.Join(u, ","))
+	}
 	fmt.Printf("transmission-remote %s -t %s -r", server, strings.Join(d, ","))
 }
 
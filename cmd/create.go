@@ -0,0 +1,288 @@
+// Copyright © 2017 Charles Haynes <ceh@ceh.bz>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charles-haynes/trr/internal/bencode"
+	units "github.com/docker/go-units"
+	"github.com/spf13/cobra"
+)
+
+var (
+	createAnnounce []string
+	createWebSeeds []string
+	createInclude  []string
+	createExclude  []string
+	createPrivate  bool
+	createAdd      bool
+	createSource   string
+	createComment  string
+	createPiece    string
+	createOutput   string
+)
+
+var torrentCreateCmd = &cobra.Command{
+	Use:   "create <path>",
+	Short: "Create a .torrent file",
+	Long: `Hash a file or directory and write a .torrent for it.
+
+Each --announce is a tier of trackers; separate the URLs of a tier with commas.
+Without --piece-size the piece size is chosen from the total size. --include
+and --exclude take globs matched against both a file's path inside the
+directory and its base name; excluding a directory skips all of it.
+
+With --add the new torrent is added to the server, with the directory holding
+path as its download directory, so the server has to see the data at the
+same path.
+
+Example:
+trr torrent create -a https://t1/announce,https://t2/announce --private --exclude '*.nfo' ./album`,
+	Args: cobra.ExactArgs(1),
+	Run:  doTorrentCreate,
+}
+
+// piece sizes, and how many pieces the automatic piece size aims for at most
+const (
+	minPieceLength   = 16 * 1024
+	maxPieceLength   = 16 * 1024 * 1024
+	targetPieceCount = 1500
+)
+
+// autoPieceLength picks the smallest power of two piece size that keeps the
+// piece count near targetPieceCount.
+func autoPieceLength(total int64) int64 {
+	pl := int64(minPieceLength)
+	for total/pl > targetPieceCount && pl < maxPieceLength {
+		pl *= 2
+	}
+	return pl
+}
+
+func parsePieceLength(s string) (int64, error) {
+	n, err := units.RAMInBytes(s)
+	if err != nil {
+		return 0, err
+	}
+	if n < minPieceLength || n > maxPieceLength || n&(n-1) != 0 {
+		return 0, fmt.Errorf("piece size %s must be a power of two from 16KiB to 16MiB", s)
+	}
+	return n, nil
+}
+
+// globMatch reports whether rel, or its base name, matches one of globs.
+func globMatch(globs []string, rel string) bool {
+	for _, g := range globs {
+		if ok, _ := filepath.Match(g, rel); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(g, filepath.Base(rel)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// collectFiles returns the files to put in a torrent for root, with their
+// paths relative to root. A plain file gives a single file torrent, whose
+// one file has an empty relative path.
+func collectFiles(root string) ([]diskFile, []string, error) {
+	fi, err := os.Stat(root)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !fi.IsDir() {
+		return []diskFile{{path: root, length: fi.Size()}}, []string{""}, nil
+	}
+	files, rels := []diskFile{}, []string{}
+	err = filepath.Walk(root, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if globMatch(createExclude, rel) {
+			if fi.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+		if len(createInclude) > 0 && !globMatch(createInclude, rel) {
+			return nil
+		}
+		files = append(files, diskFile{path: path, length: fi.Size()})
+		rels = append(rels, rel)
+		return nil
+	})
+	if err == nil && len(files) == 0 {
+		err = errors.New("no files to add")
+	}
+	return files, rels, err
+}
+
+func doTorrentCreate(cmd *cobra.Command, args []string) {
+	root, err := filepath.Abs(args[0])
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	files, rels, err := collectFiles(root)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	var total int64
+	for _, f := range files {
+		total += f.length
+	}
+	if total == 0 {
+		fmt.Println("nothing to hash, all files are empty")
+		return
+	}
+	pl := autoPieceLength(total)
+	if createPiece != "" {
+		if pl, err = parsePieceLength(createPiece); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+
+	p := newProgress(total)
+	hs := hashPieces(files, pl, p)
+	p.finish()
+	pieces := make([]byte, 0, len(hs)*sha1.Size)
+	for i, h := range hs {
+		if h.err != nil {
+			fmt.Printf("piece %d: %s\n", i, h.err)
+			return
+		}
+		pieces = append(pieces, h.sum[:]...)
+	}
+
+	name := filepath.Base(root)
+	info := map[string]interface{}{
+		"name":         name,
+		"piece length": pl,
+		"pieces":       pieces,
+	}
+	if rels[0] == "" {
+		info["length"] = files[0].length
+	} else {
+		fs := []interface{}{}
+		for i, f := range files {
+			fs = append(fs, map[string]interface{}{
+				"length": f.length,
+				"path":   strings.Split(rels[i], "/"),
+			})
+		}
+		info["files"] = fs
+	}
+	if createPrivate {
+		info["private"] = 1
+	}
+	if createSource != "" {
+		info["source"] = createSource
+	}
+	t := map[string]interface{}{
+		"info":          info,
+		"created by":    "trr",
+		"creation date": time.Now().Unix(),
+	}
+	tiers := []interface{}{}
+	for _, a := range createAnnounce {
+		tier := []string{}
+		for _, u := range strings.Split(a, ",") {
+			if u = strings.TrimSpace(u); u != "" {
+				tier = append(tier, u)
+			}
+		}
+		if len(tier) > 0 {
+			tiers = append(tiers, tier)
+		}
+	}
+	if len(tiers) > 0 {
+		t["announce"] = tiers[0].([]string)[0]
+		t["announce-list"] = tiers
+	}
+	if createComment != "" {
+		t["comment"] = createComment
+	}
+	if len(createWebSeeds) > 0 {
+		t["url-list"] = createWebSeeds
+	}
+	data, err := bencode.Encode(t)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	out := createOutput
+	if out == "" {
+		out = name + ".torrent"
+	}
+	if err = ioutil.WriteFile(out, data, 0644); err != nil {
+		fmt.Println(err)
+		return
+	}
+	m, err := parseMetainfo(data)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("Torrent file: %s\n", out)
+	printMetainfo(m)
+	if !createAdd {
+		return
+	}
+	addFile(out, map[string]interface{}{"download-dir": filepath.Dir(root)})
+}
+
+func init() {
+	torrentCmd.AddCommand(torrentCreateCmd)
+
+	f := torrentCreateCmd.Flags()
+	f.StringArrayVarP(&createAnnounce, "announce", "a", nil, "tier of announce URLs, separated by commas; repeat for more tiers")
+	f.StringArrayVar(&createWebSeeds, "web-seed", nil, "web seed URL; repeat for more")
+	f.StringArrayVar(&createInclude, "include", nil, "only add files matching this glob; repeat for more")
+	f.StringArrayVar(&createExclude, "exclude", nil, "leave out files matching this glob; repeat for more")
+	f.BoolVar(&createPrivate, "private", false, "mark the torrent private")
+	f.StringVar(&createSource, "source", "", "source tag")
+	f.StringVar(&createComment, "comment", "", "comment")
+	f.StringVar(&createPiece, "piece-size", "", "piece size, e.g. 4MiB (default chosen from the total size)")
+	f.StringVarP(&createOutput, "output", "o", "", "file to write (default <name>.torrent)")
+	f.BoolVar(&createAdd, "add", false, "add the torrent to the server")
+}
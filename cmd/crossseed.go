@@ -0,0 +1,173 @@
+// Copyright © 2017 Charles Haynes <ceh@ceh.bz>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var crossseedAdd bool
+
+// crossseedCmd represents the crossseed command
+var crossseedCmd = &cobra.Command{
+	Use:   "crossseed",
+	Short: "Find torrents to cross-seed",
+}
+
+var crossseedFindCmd = &cobra.Command{
+	Use:   "find <dir>",
+	Short: "Match .torrent files against existing torrents",
+	Long: `Match each .torrent file in dir against the selected torrents by their file
+lists, comparing every file's name and size. Torrents the server already has
+are skipped.
+
+With --add each match is added paused, with the download directory of the
+complete torrent it matched, and then verified so it can start seeding.`,
+	Args: cobra.ExactArgs(1),
+	Run:  doCrossseedFind,
+}
+
+// fileSetKey identifies a set of files by their paths and sizes.
+func fileSetKey(paths []string, lengths []int64) string {
+	fs := make([]string, len(paths))
+	for i := range paths {
+		fs[i] = fmt.Sprintf("%s\x00%d", paths[i], lengths[i])
+	}
+	sort.Strings(fs)
+	return strings.Join(fs, "\x00")
+}
+
+func torrentFileSetKey(t *rpcTorrent) string {
+	paths, lengths := []string{}, []int64{}
+	for _, f := range t.Files {
+		paths = append(paths, f.Name)
+		lengths = append(lengths, f.Length)
+	}
+	return fileSetKey(paths, lengths)
+}
+
+func metainfoFileSetKey(m *metainfo) string {
+	paths, lengths := []string{}, []int64{}
+	for _, f := range m.files {
+		if !f.pad {
+			paths = append(paths, f.path)
+			lengths = append(lengths, f.length)
+		}
+	}
+	return fileSetKey(paths, lengths)
+}
+
+func doCrossseedFind(cmd *cobra.Command, args []string) {
+	paths, err := filepath.Glob(filepath.Join(args[0], "*.torrent"))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	x := getRPC()
+	ids, err := getTorrents()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	ts, err := x.torrentGet(ids, []string{"id", "name", "hashString", "downloadDir", "files", "percentDone"})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	have := map[string]bool{}
+	byFiles := map[string][]*rpcTorrent{}
+	for _, t := range ts {
+		have[strings.ToLower(t.HashString)] = true
+		k := torrentFileSetKey(t)
+		byFiles[k] = append(byFiles[k], t)
+	}
+	matched := 0
+	for _, path := range paths {
+		m, err := loadMetainfo(path)
+		if err != nil {
+			fmt.Printf("%s: %s\n", path, err)
+			continue
+		}
+		if have[m.infoHashV1] {
+			continue
+		}
+		ms := byFiles[metainfoFileSetKey(m)]
+		if len(ms) == 0 {
+			continue
+		}
+		matched++
+		var best *rpcTorrent
+		for _, t := range ms {
+			if best == nil || t.PercentDone > best.PercentDone {
+				best = t
+			}
+		}
+		fmt.Printf("%s: matches %d %s in %s\n", path, best.ID, best.Name, best.DownloadDir)
+		if !crossseedAdd {
+			continue
+		}
+		if best.PercentDone < 1 {
+			fmt.Printf("  not added, torrent %d is only %.0f%% done\n", best.ID, best.PercentDone*100)
+			continue
+		}
+		if err := crossseed(x, path, best.DownloadDir); err != nil {
+			fmt.Printf("  %s\n", err)
+		}
+	}
+	fmt.Printf("%d of %d torrents match\n", matched, len(paths))
+}
+
+// crossseed adds the .torrent at path paused with its data in dir, and has
+// the server verify it.
+func crossseed(x *rpcClient, path, dir string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	t, dup, err := x.torrentAdd(map[string]interface{}{
+		"metainfo":     base64.StdEncoding.EncodeToString(data),
+		"download-dir": dir,
+		"paused":       true,
+	})
+	if err != nil {
+		return err
+	}
+	if dup {
+		fmt.Printf("  already added as %d\n", t.ID)
+		return nil
+	}
+	fmt.Printf("  added as %d, verifying\n", t.ID)
+	return x.call("torrent-verify", map[string]interface{}{"ids": []int{t.ID}}, nil)
+}
+
+func init() {
+	RootCmd.AddCommand(crossseedCmd)
+	crossseedCmd.AddCommand(crossseedFindCmd)
+
+	crossseedFindCmd.Flags().BoolVar(&crossseedAdd, "add", false, "add matches paused and verify them")
+}
@@ -0,0 +1,84 @@
+// Copyright © 2017 Charles Haynes <ceh@ceh.bz>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var assumeYes bool
+
+// daemonCmd represents the daemon command
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Control the transmission daemon",
+}
+
+var daemonShutdownCmd = &cobra.Command{
+	Use:   "shutdown",
+	Short: "Shut down the daemon",
+	Long: `Shut down the transmission daemon. Asks for confirmation first unless
+--yes is given.`,
+	Args: cobra.NoArgs,
+	Run:  doDaemonShutdown,
+}
+
+// confirm asks the user a yes or no question on the terminal. It is true
+// without asking when --yes was given.
+func confirm(question string) bool {
+	if assumeYes {
+		return true
+	}
+	fmt.Printf("%s [y/N] ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
+
+func doDaemonShutdown(cmd *cobra.Command, args []string) {
+	if !confirm(fmt.Sprintf("Shut down transmission on %s?", server)) {
+		return
+	}
+	if err := getRPC().call("session-close", nil, nil); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("Shutting down")
+}
+
+func init() {
+	RootCmd.AddCommand(daemonCmd)
+	daemonCmd.AddCommand(daemonShutdownCmd)
+
+	daemonShutdownCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "don't ask for confirmation")
+}
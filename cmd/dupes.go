@@ -0,0 +1,262 @@
+// Copyright © 2017 Charles Haynes <ceh@ceh.bz>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	units "github.com/docker/go-units"
+	"github.com/spf13/cobra"
+)
+
+var orphansDelete bool
+
+// dupesCmd represents the dupes command
+var dupesCmd = &cobra.Command{
+	Use:   "dupes",
+	Short: "Find duplicate torrents",
+	Long: `Find groups of the selected torrents that share an info hash, have identical
+file sets (every file's name and size the same) or keep their data at the
+same path.`,
+	Args: cobra.NoArgs,
+	Run:  doDupes,
+}
+
+// orphansCmd represents the orphans command
+var orphansCmd = &cobra.Command{
+	Use:   "orphans <dir>...",
+	Short: "Find data on disk no torrent uses",
+	Long: `Walk each dir, normally the server's download directories, and list the
+files and directories that no torrent's files are in, with their total size.
+A directory none of whose contents are used is listed once, as a whole.
+
+Files of torrents still downloading to the incomplete directory count as used.
+Paths are compared as the server reports them, so run this where the download
+directories have the same paths as on the server. With --delete the orphans
+are removed after confirmation; it refuses when the server has no torrents or
+a dir isn't inside a torrent's download directory.`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  doOrphans,
+}
+
+func doDupes(cmd *cobra.Command, args []string) {
+	x := getRPC()
+	ids, err := getTorrents()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	ts, err := x.torrentGet(ids, []string{"id", "name", "hashString", "downloadDir", "files"})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	byHash, byFiles, byPath := map[string][]*rpcTorrent{}, map[string][]*rpcTorrent{}, map[string][]*rpcTorrent{}
+	for _, t := range ts {
+		byHash[strings.ToLower(t.HashString)] = append(byHash[strings.ToLower(t.HashString)], t)
+		if len(t.Files) > 0 {
+			k := torrentFileSetKey(t)
+			byFiles[k] = append(byFiles[k], t)
+		}
+		p := filepath.Join(t.DownloadDir, t.Name)
+		byPath[p] = append(byPath[p], t)
+	}
+	printDupes("Same hash", byHash, func(k string) string { return k })
+	printDupes("Same files", byFiles, func(k string) string {
+		return fmt.Sprintf("%d files", strings.Count(k, "\x00")/2+1)
+	})
+	printDupes("Same data path", byPath, func(k string) string { return k })
+}
+
+// printDupes prints the groups in m with more than one torrent, describing
+// each group by what describe returns for its key.
+func printDupes(kind string, m map[string][]*rpcTorrent, describe func(string) string) {
+	keys := []string{}
+	for k, ts := range m {
+		if len(ts) > 1 {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Printf("%s, %s:\n", kind, describe(k))
+		for _, t := range m[k] {
+			fmt.Printf("%5d %s in %s\n", t.ID, t.Name, t.DownloadDir)
+		}
+	}
+}
+
+// orphan is a file or directory on disk that no torrent uses.
+type orphan struct {
+	path string
+	size int64
+}
+
+// usedPaths returns the paths of the files of ts, and of every directory
+// holding them, as used by the server. Each torrent's files are looked for in
+// its download directory and in each of extra, such as the incomplete
+// directory. It also returns the directories the files are looked for in.
+func usedPaths(ts []*rpcTorrent, extra ...string) (files, dirs, roots map[string]bool) {
+	files, dirs, roots = map[string]bool{}, map[string]bool{}, map[string]bool{}
+	real := map[string]string{}
+	for _, t := range ts {
+		for _, dir := range append([]string{t.DownloadDir}, extra...) {
+			if _, ok := real[dir]; !ok {
+				real[dir] = realPath(dir)
+			}
+			dir = real[dir]
+			for _, f := range t.Files {
+				p := filepath.Join(dir, filepath.FromSlash(f.Name))
+				files[p] = true
+				files[p+".part"] = true
+				for d := filepath.Dir(p); d != dir && d != filepath.Dir(d); d = filepath.Dir(d) {
+					dirs[d] = true
+				}
+			}
+			dirs[dir] = true
+			roots[dir] = true
+		}
+	}
+	return files, dirs, roots
+}
+
+// realPath returns p made absolute, with symlinks resolved if p exists.
+func realPath(p string) string {
+	if a, err := filepath.Abs(p); err == nil {
+		p = a
+	}
+	if r, err := filepath.EvalSymlinks(p); err == nil {
+		p = r
+	}
+	return p
+}
+
+// underAny reports whether path is one of dirs or inside one of them.
+func underAny(path string, dirs map[string]bool) bool {
+	for d := range dirs {
+		if path == d || strings.HasPrefix(path, strings.TrimSuffix(d, string(filepath.Separator))+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// findOrphans walks root for files and directories not in files or dirs.
+func findOrphans(root string, files, dirs map[string]bool) ([]orphan, error) {
+	found := []orphan{}
+	err := filepath.Walk(root, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root || files[path] {
+			return nil
+		}
+		if fi.IsDir() {
+			if dirs[path] {
+				return nil
+			}
+			found = append(found, orphan{path, dirSize(path)})
+			return filepath.SkipDir
+		}
+		found = append(found, orphan{path, fi.Size()})
+		return nil
+	})
+	return found, err
+}
+
+func dirSize(dir string) int64 {
+	var n int64
+	filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err == nil && fi.Mode().IsRegular() {
+			n += fi.Size()
+		}
+		return nil
+	})
+	return n
+}
+
+func doOrphans(cmd *cobra.Command, args []string) {
+	x := getRPC()
+	ts, err := x.torrentGet(nil, []string{"id", "downloadDir", "files"})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	s, err := x.sessionGet("incomplete-dir", "incomplete-dir-enabled")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	extra := []string{}
+	if on, _ := s["incomplete-dir-enabled"].(bool); on {
+		if d, _ := s["incomplete-dir"].(string); d != "" {
+			extra = append(extra, d)
+		}
+	}
+	files, dirs, roots := usedPaths(ts, extra...)
+	if orphansDelete && len(ts) == 0 {
+		fmt.Println("the server has no torrents, refusing to delete")
+		return
+	}
+	all := []orphan{}
+	for _, root := range args {
+		root = realPath(root)
+		if orphansDelete && !underAny(root, roots) {
+			fmt.Printf("%s is not in a torrent's download directory, refusing to delete\n", root)
+			return
+		}
+		found, err := findOrphans(root, files, dirs)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		all = append(all, found...)
+	}
+	var total int64
+	for _, o := range all {
+		total += o.size
+		fmt.Printf("%9s %s\n", units.HumanSize(float64(o.size)), o.path)
+	}
+	fmt.Printf("%d orphans, %s\n", len(all), units.HumanSize(float64(total)))
+	if !orphansDelete || len(all) == 0 {
+		return
+	}
+	if !confirm(fmt.Sprintf("Delete %d orphans, %s?", len(all), units.HumanSize(float64(total)))) {
+		return
+	}
+	for _, o := range all {
+		if err := os.RemoveAll(o.path); err != nil {
+			fmt.Println(err)
+		}
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(dupesCmd)
+	RootCmd.AddCommand(orphansCmd)
+
+	orphansCmd.Flags().BoolVar(&orphansDelete, "delete", false, "delete the orphans")
+	orphansCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "don't ask for confirmation")
+}
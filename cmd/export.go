@@ -0,0 +1,177 @@
+// Copyright © 2017 Charles Haynes <ceh@ceh.bz>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export magnet links and .torrent files",
+}
+
+var exportMagnetCmd = &cobra.Command{
+	Use:   "magnet",
+	Short: "Print magnet links",
+	Long:  `Print the magnet link of each selected torrent.`,
+	Args:  cobra.NoArgs,
+	Run:   doExportMagnet,
+}
+
+var exportTorrentCmd = &cobra.Command{
+	Use:   "torrent <dir>",
+	Short: "Copy .torrent files",
+	Long: `Copy the .torrent file of each selected torrent into dir. The daemon's copy
+is used when it can be read from here; otherwise the torrent's magnet link is
+written instead, to a .magnet file.
+
+Files are named by the export-template setting, a Go template over the
+torrent's .ID, .Name, .Hash and .ShortHash, the first 16 characters of the
+hash. The default names them <name>.<short hash>.torrent.`,
+	Args: cobra.ExactArgs(1),
+	Run:  doExportTorrent,
+}
+
+// exportName is what export-template is applied to.
+type exportName struct {
+	ID        int
+	Name      string
+	Hash      string
+	ShortHash string
+}
+
+func doExportMagnet(cmd *cobra.Command, args []string) {
+	x := getRPC()
+	ids, err := getTorrents()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	ts, err := x.torrentGet(ids, []string{"id", "magnetLink"})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, t := range ts {
+		fmt.Println(t.MagnetLink)
+	}
+}
+
+func doExportTorrent(cmd *cobra.Command, args []string) {
+	tmpl, err := template.New("export-template").Parse(viper.GetString("export-template"))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	x := getRPC()
+	ids, err := getTorrents()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	ts, err := x.torrentGet(ids, []string{"id", "name", "hashString", "magnetLink", "torrentFile"})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, t := range ts {
+		var b bytes.Buffer
+		err := tmpl.Execute(&b, exportName{
+			ID:        t.ID,
+			Name:      strings.Replace(t.Name, string(filepath.Separator), "_", -1),
+			Hash:      t.HashString,
+			ShortHash: shortHash(t.HashString),
+		})
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		dst := filepath.Join(args[0], b.String())
+		in, err := os.Open(t.TorrentFile)
+		if err != nil {
+			dst = strings.TrimSuffix(dst, ".torrent") + ".magnet"
+			if err := ioutil.WriteFile(dst, []byte(t.MagnetLink+"\n"), 0644); err != nil {
+				fmt.Println(err)
+				continue
+			}
+			fmt.Printf("magnet -> %s\n", dst)
+			continue
+		}
+		err = copyFile(in, dst)
+		in.Close()
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		fmt.Printf("%s -> %s\n", t.TorrentFile, dst)
+	}
+}
+
+// shortHash is the prefix of a hash used in .torrent file names.
+func shortHash(h string) string {
+	if len(h) > 16 {
+		return h[:16]
+	}
+	return h
+}
+
+// copyFile writes what is read from in to dst, removing dst if that fails.
+func copyFile(in io.Reader, dst string) error {
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, in); err == nil {
+		err = out.Close()
+	} else {
+		out.Close()
+	}
+	if err != nil {
+		os.Remove(dst)
+	}
+	return err
+}
+
+func init() {
+	RootCmd.AddCommand(exportCmd)
+	exportCmd.AddCommand(exportMagnetCmd)
+	exportCmd.AddCommand(exportTorrentCmd)
+
+	exportTorrentCmd.Flags().String("template", "", "template for file names (default from export-template)")
+	err := viper.BindPFlag("export-template", exportTorrentCmd.Flags().Lookup("template"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	viper.SetDefault("export-template", "{{.Name}}.{{.ShortHash}}.torrent")
+}
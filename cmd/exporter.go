@@ -0,0 +1,205 @@
+// Copyright © 2017 Charles Haynes <ceh@ceh.bz>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var exporterPerTorrent bool
+
+// exporterCmd represents the exporter command
+var exporterCmd = &cobra.Command{
+	Use:   "exporter",
+	Short: "Serve Prometheus metrics",
+	Long: `Serve metrics about the server in the Prometheus text format at
+http://<exporter-listen>/metrics. The server is queried on each scrape.
+
+Exported are the session rates and cumulative bytes, the number of torrents in
+each status (what list shows for torrents without an error, lower cased, or
+error), and per tracker host the number of torrents, successful and failed
+announces, and seeders and leechers.
+
+--per-torrent adds the ratio, size and rates of every torrent, labelled by
+hash and name. That is a few series per torrent, so leave it off for large
+servers.`,
+	Args: cobra.NoArgs,
+	Run:  doExporter,
+}
+
+// exporter answers scrapes by querying x.
+type exporter struct {
+	x          *rpcClient
+	perTorrent bool
+}
+
+func (e *exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var b bytes.Buffer
+	if err := e.writeMetrics(&b); err != nil {
+		log.Print(err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	if _, err := b.WriteTo(w); err != nil {
+		log.Print(err)
+	}
+}
+
+// writeMetrics writes all metrics to w.
+func (e *exporter) writeMetrics(w io.Writer) error {
+	st, err := e.x.sessionStats()
+	if err != nil {
+		return err
+	}
+	fields := []string{"id", "status", "error", "errorString", "rateDownload", "rateUpload", "trackerStats"}
+	if e.perTorrent {
+		fields = append(fields, "hashString", "name", "uploadRatio", "sizeWhenDone")
+	}
+	ts, err := e.x.torrentGet(nil, fields)
+	if err != nil {
+		return err
+	}
+	m := metricWriter{w: w}
+
+	m.metric("transmission_download_bytes_per_second", "gauge", "Current session download rate.")
+	m.sample("transmission_download_bytes_per_second", nil, float64(st.DownloadSpeed))
+	m.metric("transmission_upload_bytes_per_second", "gauge", "Current session upload rate.")
+	m.sample("transmission_upload_bytes_per_second", nil, float64(st.UploadSpeed))
+	m.metric("transmission_downloaded_bytes_total", "counter", "Bytes downloaded over all sessions.")
+	m.sample("transmission_downloaded_bytes_total", nil, float64(st.Cumulative.DownloadedBytes))
+	m.metric("transmission_uploaded_bytes_total", "counter", "Bytes uploaded over all sessions.")
+	m.sample("transmission_uploaded_bytes_total", nil, float64(st.Cumulative.UploadedBytes))
+
+	byStatus := map[string]int{}
+	for _, t := range ts {
+		byStatus[statusCategory(t)]++
+	}
+	m.metric("transmission_torrents", "gauge", "Number of torrents by status.")
+	for _, s := range sortedKeys(byStatus) {
+		m.sample("transmission_torrents", []string{"status", s}, float64(byStatus[s]))
+	}
+
+	hs := trackerHosts(ts)
+	for _, g := range []struct {
+		name, help string
+		value      func(h *trackerHealth) int
+	}{
+		{"transmission_tracker_torrents", "Number of torrents using the tracker.", func(h *trackerHealth) int { return len(h.torrents) }},
+		{"transmission_tracker_announce_ok", "Torrents whose last announce to the tracker succeeded.", func(h *trackerHealth) int { return h.ok }},
+		{"transmission_tracker_announce_failed", "Torrents whose last announce to the tracker failed.", func(h *trackerHealth) int { return h.failed }},
+		{"transmission_tracker_seeders", "Seeders reported by the tracker, summed over torrents.", func(h *trackerHealth) int { return h.seeders }},
+		{"transmission_tracker_leechers", "Leechers reported by the tracker, summed over torrents.", func(h *trackerHealth) int { return h.leechers }},
+	} {
+		m.metric(g.name, "gauge", g.help)
+		for _, h := range hs {
+			m.sample(g.name, []string{"host", h.host}, float64(g.value(h)))
+		}
+	}
+
+	if e.perTorrent {
+		for _, g := range []struct {
+			name, help string
+			value      func(t *rpcTorrent) float64
+		}{
+			{"transmission_torrent_ratio", "Upload ratio of the torrent.", func(t *rpcTorrent) float64 { return t.UploadRatio }},
+			{"transmission_torrent_size_bytes", "Size of the torrent when done.", func(t *rpcTorrent) float64 { return float64(t.SizeWhenDone) }},
+			{"transmission_torrent_download_bytes_per_second", "Download rate of the torrent.", func(t *rpcTorrent) float64 { return float64(t.RateDownload) }},
+			{"transmission_torrent_upload_bytes_per_second", "Upload rate of the torrent.", func(t *rpcTorrent) float64 { return float64(t.RateUpload) }},
+		} {
+			m.metric(g.name, "gauge", g.help)
+			for _, t := range ts {
+				m.sample(g.name, []string{"hash", t.HashString, "name", t.Name}, g.value(t))
+			}
+		}
+	}
+	return m.err
+}
+
+func sortedKeys(m map[string]int) []string {
+	ks := []string{}
+	for k := range m {
+		ks = append(ks, k)
+	}
+	sort.Strings(ks)
+	return ks
+}
+
+// metricWriter writes the Prometheus text format, keeping the first error.
+type metricWriter struct {
+	w   io.Writer
+	err error
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func (m *metricWriter) printf(format string, a ...interface{}) {
+	if m.err == nil {
+		_, m.err = fmt.Fprintf(m.w, format, a...)
+	}
+}
+
+func (m *metricWriter) metric(name, kind, help string) {
+	m.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// sample writes one value of name. labels alternates label names and values.
+func (m *metricWriter) sample(name string, labels []string, v float64) {
+	ls := []string{}
+	for i := 0; i+1 < len(labels); i += 2 {
+		ls = append(ls, fmt.Sprintf(`%s="%s"`, labels[i], labelEscaper.Replace(labels[i+1])))
+	}
+	if len(ls) > 0 {
+		name += "{" + strings.Join(ls, ",") + "}"
+	}
+	m.printf("%s %s\n", name, strconv.FormatFloat(v, 'g', -1, 64))
+}
+
+func doExporter(cmd *cobra.Command, args []string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", &exporter{x: getRPC(), perTorrent: exporterPerTorrent})
+	addr := viper.GetString("exporter-listen")
+	log.Printf("serving metrics on http://%s/metrics", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		fmt.Println(err)
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(exporterCmd)
+
+	exporterCmd.Flags().String("listen", "", "address to serve metrics on (default from exporter-listen)")
+	exporterCmd.Flags().BoolVar(&exporterPerTorrent, "per-torrent", false, "also export ratio, size and rates of every torrent")
+	if err := viper.BindPFlag("exporter-listen", exporterCmd.Flags().Lookup("listen")); err != nil {
+		log.Fatal(err)
+	}
+	viper.SetDefault("exporter-listen", ":9190")
+}
@@ -0,0 +1,163 @@
+// Copyright © 2017 Charles Haynes <ceh@ceh.bz>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeTransmission answers session-stats and torrent-get like the daemon,
+// including the session id handshake.
+func fakeTransmission(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(sessionIDHeader) != "fake-id" {
+			w.Header().Set(sessionIDHeader, "fake-id")
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		var req rpcRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+			return
+		}
+		switch req.Method {
+		case "session-stats":
+			w.Write([]byte(`{"result":"success","arguments":{
+				"downloadSpeed":5,"uploadSpeed":7,
+				"cumulative-stats":{"uploadedBytes":100,"downloadedBytes":200}}}`))
+		case "torrent-get":
+			w.Write([]byte(`{"result":"success","arguments":{"torrents":[
+				{"id":1,"name":"Some \"Show\"","hashString":"ff00","status":6,"rateUpload":3,
+				 "uploadRatio":1.5,"sizeWhenDone":10,
+				 "trackerStats":[{"host":"tracker.example:443","hasAnnounced":true,
+				  "lastAnnounceSucceeded":true,"seederCount":4,"leecherCount":2}]},
+				{"id":3,"name":"broken","hashString":"dd00","status":0,
+				 "error":3,"errorString":"No data found! Ensure /data/broken is mounted"},
+				{"id":2,"name":"other","hashString":"ee00","status":0,
+				 "trackerStats":[{"host":"tracker.example:443","hasAnnounced":true,
+				  "lastAnnounceResult":"timed out"}]}]}}`))
+		default:
+			t.Errorf("unexpected method %s", req.Method)
+		}
+	}))
+}
+
+var sampleLine = regexp.MustCompile(`^[a-z_]+(\{[a-z]+="([^"\\]|\\.)*"(,[a-z]+="([^"\\]|\\.)*")*\})? [-+0-9.e]+$`)
+
+func scrape(t *testing.T, e *exporter) string {
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("content type %q", ct)
+	}
+	return w.Body.String()
+}
+
+func TestExporter(t *testing.T) {
+	srv := fakeTransmission(t)
+	defer srv.Close()
+	out := scrape(t, &exporter{x: newRPC(srv.URL, "", ""), perTorrent: true})
+
+	types := map[string]bool{}
+	for _, l := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+		if strings.HasPrefix(l, "# TYPE ") {
+			types[strings.Fields(l)[2]] = true
+			continue
+		}
+		if strings.HasPrefix(l, "# HELP ") {
+			continue
+		}
+		if !sampleLine.MatchString(l) {
+			t.Errorf("malformed sample %q", l)
+			continue
+		}
+		name := l[:strings.IndexAny(l, "{ ")]
+		if !types[name] {
+			t.Errorf("sample %q before its TYPE line", l)
+		}
+	}
+	for _, want := range []string{
+		"transmission_download_bytes_per_second 5",
+		"transmission_upload_bytes_per_second 7",
+		"transmission_downloaded_bytes_total 200",
+		"transmission_uploaded_bytes_total 100",
+		`transmission_torrents{status="uploading"} 1`,
+		`transmission_torrents{status="stopped"} 1`,
+		`transmission_torrents{status="error"} 1`,
+		`transmission_tracker_torrents{host="tracker.example:443"} 2`,
+		`transmission_tracker_announce_ok{host="tracker.example:443"} 1`,
+		`transmission_tracker_announce_failed{host="tracker.example:443"} 1`,
+		`transmission_tracker_seeders{host="tracker.example:443"} 4`,
+		`transmission_tracker_leechers{host="tracker.example:443"} 2`,
+		`transmission_torrent_ratio{hash="ff00",name="Some \"Show\""} 1.5`,
+		`transmission_torrent_upload_bytes_per_second{hash="ff00",name="Some \"Show\""} 3`,
+	} {
+		if !strings.Contains(out, want+"\n") {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "No data found") {
+		t.Errorf("error string used as a label:\n%s", out)
+	}
+}
+
+func TestExporterPerTorrentOff(t *testing.T) {
+	srv := fakeTransmission(t)
+	defer srv.Close()
+	out := scrape(t, &exporter{x: newRPC(srv.URL, "", "")})
+	if strings.Contains(out, "transmission_torrent_") {
+		t.Errorf("per torrent metrics without perTorrent:\n%s", out)
+	}
+}
+
+func TestExporterConcurrentScrapes(t *testing.T) {
+	srv := fakeTransmission(t)
+	defer srv.Close()
+	e := &exporter{x: newRPC(srv.URL, "", "")}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			scrape(t, e)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestExporterServerDown(t *testing.T) {
+	srv := fakeTransmission(t)
+	srv.Close()
+	w := httptest.NewRecorder()
+	(&exporter{x: newRPC(srv.URL, "", "")}).ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("status %d, want %d", w.Code, http.StatusBadGateway)
+	}
+}
@@ -43,9 +43,14 @@ to quickly create a Cobra application.`,
 }
 
 func doInfoFiles(cmd *cobra.Command, args []string) {
+	ids, err := getTorrents()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 	x := getServer()
 	c := transmission.NewGetTorrentsCmd()
-	c.Arguments.Ids = getTorrents()
+	c.Arguments.Ids = ids
 	c.Arguments.Fields = []string{"files", "fileStats", "id", "name", "priorities", "wanted"}
 	res, err := x.ExecuteCommand(c)
 	if err != nil {
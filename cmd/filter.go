@@ -0,0 +1,227 @@
+// Copyright © 2017 Charles Haynes <ceh@ceh.bz>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	units "github.com/docker/go-units"
+)
+
+// torrentFilter reports whether a torrent is selected by a filter.
+type torrentFilter func(t *rpcTorrent) bool
+
+// filterFields are the torrent-get fields parseFilter's terms look at.
+var filterFields = []string{
+	"addedDate",
+	"error",
+	"errorString",
+	"labels",
+	"name",
+	"rateDownload",
+	"rateUpload",
+	"secondsSeeding",
+	"sizeWhenDone",
+	"status",
+	"trackers",
+	"uploadRatio",
+}
+
+// parseFilter parses a comma separated list of terms, all of which a torrent
+// has to match. A term is one of
+//
+//	label:<name>      has the label
+//	tracker:<host>    has a tracker whose host ends in host
+//	error:<text>      has an error containing text
+//	name:<text>       has a name containing text
+//	age<d, age>d      was added less or more than d ago, e.g. 30d or 12h
+//	seedtime<d, >d    has seeded for less or more than d
+//	ratio<r, ratio>r  has an upload ratio below or above r
+//	size<s, size>s    is smaller or larger than s when done, e.g. 4G
+//	<status>          has that status as shown by list, e.g. idle or stopped,
+//	                  or is seeding, downloading or has an error
+//
+// Status terms look at what the torrent is doing, even when list shows its
+// error instead, so a stopped torrent with an error matches stopped. Unknown
+// status words are an error. Text matches ignore case and need a value. Any
+// term can be negated with a leading !.
+func parseFilter(s string) (torrentFilter, error) {
+	fs := []torrentFilter{}
+	for _, term := range strings.Split(s, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		f, err := parseFilterTerm(strings.TrimPrefix(term, "!"))
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(term, "!") {
+			f = not(f)
+		}
+		fs = append(fs, f)
+	}
+	return func(t *rpcTorrent) bool {
+		for _, f := range fs {
+			if !f(t) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// statusTerms are the bare filter words. Besides what list shows for a
+// torrent without an error, seeding and downloading match the torrent's state
+// whatever its rates, and error matches torrents with an error.
+var statusTerms = map[string]torrentFilter{
+	"stopped":       isActivity("Stopped"),
+	"wait verify":   isActivity("Wait Verify"),
+	"verifying":     isActivity("Verifying"),
+	"wait download": isActivity("Wait Download"),
+	"wait seed":     isActivity("Wait Seed"),
+	"idle":          isActivity("Idle"),
+	"both":          isActivity("Both"),
+	"uploading":     isActivity("Uploading"),
+	"downloading":   func(t *rpcTorrent) bool { return t.Status == statusDownloading },
+	"seeding":       func(t *rpcTorrent) bool { return t.Status == statusSeeding },
+	"error":         func(t *rpcTorrent) bool { return t.Error != 0 || t.ErrorString != "" },
+}
+
+func isActivity(a string) torrentFilter {
+	return func(t *rpcTorrent) bool { return activity(t) == a }
+}
+
+func not(f torrentFilter) torrentFilter {
+	return func(t *rpcTorrent) bool { return !f(t) }
+}
+
+func parseFilterTerm(term string) (torrentFilter, error) {
+	i := strings.IndexAny(term, ":<>")
+	if i < 0 {
+		f, ok := statusTerms[strings.ToLower(term)]
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown filter term", term)
+		}
+		return f, nil
+	}
+	key, op, value := term[:i], term[i], term[i+1:]
+	if value == "" {
+		return nil, fmt.Errorf("%s: missing value", term)
+	}
+	if op == ':' {
+		switch key {
+		case "label":
+			return func(t *rpcTorrent) bool { return hasLabel(t, value) }, nil
+		case "tracker":
+			return func(t *rpcTorrent) bool { return hasTracker(t, value) }, nil
+		case "error":
+			return func(t *rpcTorrent) bool { return containsFold(t.ErrorString, value) }, nil
+		case "name":
+			return func(t *rpcTorrent) bool { return containsFold(t.Name, value) }, nil
+		}
+		return nil, fmt.Errorf("%s: unknown filter term", term)
+	}
+	var get func(t *rpcTorrent) float64
+	var parse func(string) (float64, error)
+	switch key {
+	case "age":
+		get = func(t *rpcTorrent) float64 { return float64(time.Now().Unix() - t.AddedDate) }
+		parse = parseAge
+	case "seedtime":
+		get = func(t *rpcTorrent) float64 { return float64(t.SecondsSeeding) }
+		parse = parseAge
+	case "ratio":
+		get = func(t *rpcTorrent) float64 { return t.UploadRatio }
+		parse = func(s string) (float64, error) { return strconv.ParseFloat(s, 64) }
+	case "size":
+		get = func(t *rpcTorrent) float64 { return float64(t.SizeWhenDone) }
+		parse = func(s string) (float64, error) {
+			n, err := units.FromHumanSize(s)
+			return float64(n), err
+		}
+	default:
+		return nil, fmt.Errorf("%s: unknown filter term", term)
+	}
+	v, err := parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", term, err)
+	}
+	if op == '<' {
+		return func(t *rpcTorrent) bool { return get(t) < v }, nil
+	}
+	return func(t *rpcTorrent) bool { return get(t) > v }, nil
+}
+
+// parseAge parses a duration in seconds, allowing days and weeks as d and w
+// as well as the units time.ParseDuration knows.
+func parseAge(s string) (float64, error) {
+	for suffix, secs := range map[string]float64{"d": secsPerDay, "w": 7 * secsPerDay} {
+		if strings.HasSuffix(s, suffix) {
+			n, err := strconv.ParseFloat(strings.TrimSuffix(s, suffix), 64)
+			return n * secs, err
+		}
+	}
+	d, err := time.ParseDuration(s)
+	return d.Seconds(), err
+}
+
+func containsFold(s, sub string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(sub))
+}
+
+func hasTracker(t *rpcTorrent, host string) bool {
+	for _, tr := range t.Trackers {
+		u, err := url.Parse(tr.Announce)
+		if err != nil {
+			continue
+		}
+		h := u.Hostname()
+		if h == host || strings.HasSuffix(h, "."+host) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasLabel(t *rpcTorrent, label string) bool {
+	for _, l := range t.Labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+// filterTorrents returns the torrents in ts selected by f.
+func filterTorrents(ts []*rpcTorrent, f torrentFilter) []*rpcTorrent {
+	r := []*rpcTorrent{}
+	for _, t := range ts {
+		if f(t) {
+			r = append(r, t)
+		}
+	}
+	return r
+}
@@ -0,0 +1,236 @@
+// Copyright © 2017 Charles Haynes <ceh@ceh.bz>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	units "github.com/docker/go-units"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// freeCmd represents the free command
+var freeCmd = &cobra.Command{
+	Use:   "free [path]",
+	Short: "Free space on the server",
+	Long: `Show the free space at path on the server, by default its download
+directory.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  doFree,
+}
+
+// planCmd represents the plan command
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Check there is room for incomplete torrents",
+	Long: `Add up how much the selected torrents still have to download for their
+wanted files, per download directory, and compare it with the free space
+there. When the incomplete directory is enabled, torrents download there and
+need room for their whole size in the download directory once they finish,
+unless both are listed as the same filesystem below.
+
+Each directory is checked against its own free space, but directories on the
+same filesystem share it, so together they can still fill it. List such
+directories in the config to check them together:
+
+plan-filesystems:
+  - [/data/movies, /data/tv, /data/incomplete]
+
+Warns about every directory, or group of them, that would fill up.`,
+	Args: cobra.NoArgs,
+	Run:  doPlan,
+}
+
+// sessionDownloadDir returns the server's default download directory.
+func sessionDownloadDir(x *rpcClient) (string, error) {
+	s, err := x.sessionGet("download-dir")
+	if err != nil {
+		return "", err
+	}
+	d, _ := s["download-dir"].(string)
+	return d, nil
+}
+
+func doFree(cmd *cobra.Command, args []string) {
+	x := getRPC()
+	var path string
+	if len(args) > 0 {
+		path = args[0]
+	} else {
+		var err error
+		if path, err = sessionDownloadDir(x); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+	free, err := x.freeSpace(path)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("%s free in %s\n", units.HumanSize(float64(free)), path)
+}
+
+// dirPlan is the space still needed in one directory: left is what torrents
+// still write there, moved what arrives there from the incomplete directory
+// when they finish.
+type dirPlan struct {
+	dir      string
+	torrents int
+	left     int64
+	moved    int64
+	free     int64
+	err      error
+}
+
+func doPlan(cmd *cobra.Command, args []string) {
+	x := getRPC()
+	ids, err := getTorrents()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	ts, err := x.torrentGet(ids, []string{"id", "downloadDir", "leftUntilDone", "sizeWhenDone"})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	s, err := x.sessionGet("incomplete-dir", "incomplete-dir-enabled")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	incomplete := ""
+	if on, _ := s["incomplete-dir-enabled"].(bool); on {
+		incomplete, _ = s["incomplete-dir"].(string)
+	}
+	dirs := map[string]*dirPlan{}
+	plan := func(dir string) *dirPlan {
+		d, ok := dirs[dir]
+		if !ok {
+			d = &dirPlan{dir: dir}
+			dirs[dir] = d
+		}
+		return d
+	}
+	for _, t := range ts {
+		if t.LeftUntilDone <= 0 {
+			continue
+		}
+		d := plan(t.DownloadDir)
+		d.torrents++
+		if incomplete == "" {
+			d.left += t.LeftUntilDone
+			continue
+		}
+		i := plan(incomplete)
+		i.torrents++
+		i.left += t.LeftUntilDone
+		d.moved += t.SizeWhenDone
+	}
+	ds := []*dirPlan{}
+	for _, d := range dirs {
+		d.free, d.err = x.freeSpace(d.dir)
+		ds = append(ds, d)
+	}
+	sort.Slice(ds, func(i, j int) bool { return ds[i].dir < ds[j].dir })
+
+	// Directories configured to share a filesystem are checked together, and
+	// moving finished data between them needs no space.
+	var groups [][]string
+	if err := viper.UnmarshalKey("plan-filesystems", &groups); err != nil {
+		fmt.Println("plan-filesystems:", err)
+		return
+	}
+	group := map[string]int{}
+	for i, g := range groups {
+		for _, dir := range g {
+			group[filepath.Clean(dir)] = i + 1
+		}
+	}
+	sameFS := func(a, b *dirPlan) bool {
+		if a == b {
+			return true
+		}
+		ga := group[filepath.Clean(a.dir)]
+		return ga != 0 && ga == group[filepath.Clean(b.dir)]
+	}
+	need := func(d *dirPlan) int64 {
+		if i, ok := dirs[incomplete]; ok && sameFS(d, i) {
+			return d.left
+		}
+		return d.left + d.moved
+	}
+	fmt.Println("Torrents      Need      Free Dir")
+	for _, d := range ds {
+		if d.err != nil {
+			fmt.Printf("%8d %9s %9s %s: %s\n", d.torrents, units.HumanSize(float64(need(d))), "?", d.dir, d.err)
+			continue
+		}
+		fmt.Printf("%8d %9s %9s %s\n",
+			d.torrents,
+			units.HumanSize(float64(need(d))),
+			units.HumanSize(float64(d.free)),
+			d.dir)
+	}
+	done := map[*dirPlan]bool{}
+	for _, d := range ds {
+		if d.err != nil || done[d] {
+			continue
+		}
+		names := []string{}
+		var total int64
+		free := d.free
+		for _, e := range ds {
+			if e.err == nil && sameFS(d, e) {
+				done[e] = true
+				names = append(names, e.dir)
+				total += need(e)
+				if e.free < free {
+					free = e.free
+				}
+			}
+		}
+		if total <= free {
+			continue
+		}
+		if len(names) == 1 {
+			fmt.Printf("warning: %s needs %s more than its %s free\n",
+				d.dir,
+				units.HumanSize(float64(total-free)),
+				units.HumanSize(float64(free)))
+			continue
+		}
+		fmt.Printf("warning: %s together need %s more than their shared %s free\n",
+			strings.Join(names, ", "),
+			units.HumanSize(float64(total-free)),
+			units.HumanSize(float64(free)))
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(freeCmd)
+	RootCmd.AddCommand(planCmd)
+}
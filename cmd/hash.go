@@ -0,0 +1,181 @@
+// Copyright © 2017 Charles Haynes <ceh@ceh.bz>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	units "github.com/docker/go-units"
+)
+
+// diskFile is a file of a torrent on local disk. Pad files aren't read, they
+// are all zeros.
+type diskFile struct {
+	path   string
+	length int64
+	pad    bool
+}
+
+// pieceHash is the outcome of hashing one piece. err is set when the piece
+// couldn't be read.
+type pieceHash struct {
+	sum [sha1.Size]byte
+	err error
+}
+
+// hashPieces computes the SHA-1 of every piece of files laid end to end,
+// hashing pieces on all cores in parallel. p, which may be nil, is advanced
+// as pieces are done.
+func hashPieces(files []diskFile, pieceLength int64, p *progress) []pieceHash {
+	starts := make([]int64, len(files))
+	var total int64
+	for i, f := range files {
+		starts[i] = total
+		total += f.length
+	}
+	hs := make([]pieceHash, (total+pieceLength-1)/pieceLength)
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buf := make([]byte, pieceLength)
+			for i := range work {
+				off := int64(i) * pieceLength
+				size := pieceLength
+				if off+size > total {
+					size = total - off
+				}
+				err := readPiece(files, starts, off, buf[:size])
+				hs[i] = pieceHash{sha1.Sum(buf[:size]), err}
+				p.add(size)
+			}
+		}()
+	}
+	for i := range hs {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+	return hs
+}
+
+// readPiece fills buf with the content of files starting at offset off,
+// where starts holds the offset of each file.
+func readPiece(files []diskFile, starts []int64, off int64, buf []byte) error {
+	i := sort.Search(len(files), func(i int) bool { return starts[i]+files[i].length > off })
+	for ; len(buf) > 0 && i < len(files); i++ {
+		f := files[i]
+		n := f.length - (off - starts[i])
+		if n <= 0 {
+			continue
+		}
+		if n > int64(len(buf)) {
+			n = int64(len(buf))
+		}
+		if f.pad {
+			for j := range buf[:n] {
+				buf[j] = 0
+			}
+		} else if err := readFileAt(f.path, off-starts[i], buf[:n]); err != nil {
+			return err
+		}
+		buf = buf[n:]
+		off += n
+	}
+	return nil
+}
+
+func readFileAt(path string, off int64, buf []byte) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.ReadAt(buf, off)
+	return err
+}
+
+// progress draws a progress bar on stderr while work is done.
+type progress struct {
+	total int64
+	done  int64
+	stop  chan bool
+	wg    sync.WaitGroup
+}
+
+func newProgress(total int64) *progress {
+	p := &progress{total: total, stop: make(chan bool)}
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		t := time.NewTicker(200 * time.Millisecond)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				p.draw()
+			case <-p.stop:
+				p.draw()
+				fmt.Fprintln(os.Stderr)
+				return
+			}
+		}
+	}()
+	return p
+}
+
+func (p *progress) add(n int64) {
+	if p != nil {
+		atomic.AddInt64(&p.done, n)
+	}
+}
+
+func (p *progress) draw() {
+	const width = 40
+	done := atomic.LoadInt64(&p.done)
+	frac := 1.0
+	if p.total > 0 {
+		frac = float64(done) / float64(p.total)
+	}
+	n := int(frac * width)
+	fmt.Fprintf(os.Stderr, "\r[%s%s] %3.0f%% %s of %s",
+		strings.Repeat("#", n),
+		strings.Repeat(" ", width-n),
+		frac*100,
+		units.HumanSize(float64(done)),
+		units.HumanSize(float64(p.total)))
+}
+
+// finish draws the bar a last time and ends its line.
+func (p *progress) finish() {
+	close(p.stop)
+	p.wg.Wait()
+}
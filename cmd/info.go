@@ -22,8 +22,11 @@ package cmd
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
-	"github.com/charles-haynes/transmission"
+	units "github.com/docker/go-units"
 	"github.com/spf13/cobra"
 )
 
@@ -37,26 +40,157 @@ Takes list of torrent specifiers, and returns details about those torrents. Defa
 	Run: doInfo,
 }
 
+var infoFields = []string{
+	"activityDate",
+	"addedDate",
+	"comment",
+	"corruptEver",
+	"creator",
+	"dateCreated",
+	"doneDate",
+	"downloadDir",
+	"downloadLimit",
+	"downloadLimited",
+	"downloadedEver",
+	"error",
+	"errorString",
+	"eta",
+	"hashString",
+	"haveUnchecked",
+	"haveValid",
+	"honorsSessionLimits",
+	"id",
+	"isPrivate",
+	"labels",
+	"leftUntilDone",
+	"magnetLink",
+	"name",
+	"peersConnected",
+	"peersGettingFromUs",
+	"peersSendingToUs",
+	"percentDone",
+	"pieceCount",
+	"pieceSize",
+	"rateDownload",
+	"rateUpload",
+	"seedIdleLimit",
+	"seedIdleMode",
+	"seedRatioLimit",
+	"seedRatioMode",
+	"sizeWhenDone",
+	"status",
+	"totalSize",
+	"trackerStats",
+	"uploadLimit",
+	"uploadLimited",
+	"uploadRatio",
+	"uploadedEver",
+}
+
 func doInfo(cmd *cobra.Command, args []string) {
-	x := getServer()
-	c := transmission.NewGetTorrentsCmd()
-	c.Arguments.Ids = getTorrents()
-	c.Arguments.Fields = []string{"errorString", "hashString", "id", "name", "status"}
-	res, err := x.ExecuteCommand(c)
+	x := getRPC()
+	ids, err := getTorrents()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	ts, err := x.torrentGet(ids, infoFields)
 	if err != nil {
 		fmt.Println(err)
 		return
 	}
-	ts := res.Arguments.Torrents
-	for _, t := range ts {
-		l := fmt.Sprintf(
-			`"%d","%s","%s","%s"`,
-			t.ID,
-			t.Name,
-			t.Hash[0:16],
-			t.ErrorString)
-		fmt.Println(l)
+	for i, t := range ts {
+		if i > 0 {
+			fmt.Println()
+		}
+		printInfo(t)
+	}
+}
+
+func printInfo(t *rpcTorrent) {
+	size := func(n int64) string { return units.HumanSize(float64(n)) }
+	fmt.Printf("Torrent %d: %s\n", t.ID, t.Name)
+	infoField("Hash", t.HashString)
+	infoField("Location", t.DownloadDir)
+	infoField("Status", Status(t))
+	if len(t.Labels) > 0 {
+		infoField("Labels", strings.Join(t.Labels, ", "))
+	}
+	if t.Error != 0 || t.ErrorString != "" {
+		infoField("Error", fmt.Sprintf("%d %s", t.Error, t.ErrorString))
+	}
+
+	fmt.Println("  Transfer")
+	infoField("Size", fmt.Sprintf("%s (%s wanted)", size(t.TotalSize), size(t.SizeWhenDone)))
+	infoField("Have", fmt.Sprintf("%s (%s verified), %.1f%% done", size(t.Have()), size(t.HaveValid), t.PercentDone*100))
+	if t.LeftUntilDone > 0 {
+		infoField("Left", fmt.Sprintf("%s, eta %s", size(t.LeftUntilDone), myDuration(myETA(t))))
+	}
+	infoField("Corrupt", size(t.CorruptEver))
+	infoField("Downloaded", size(t.DownloadedEver))
+	infoField("Uploaded", size(t.UploadedEver))
+	infoField("Rates", fmt.Sprintf("%s up, %s down", size(t.RateUpload), size(t.RateDownload)))
+
+	fmt.Println("  Limits")
+	infoField("Ratio", fmt.Sprintf("%.2f, limit %s", t.UploadRatio,
+		seedLimit(t.SeedRatioMode, fmt.Sprintf("%.2f", t.SeedRatioLimit))))
+	infoField("Idle", seedLimit(t.SeedIdleMode, myDuration(int64(t.SeedIdleLimit)*secsPerMin)))
+	infoField("Upload", speedLimit(t.UploadLimited, t.UploadLimit))
+	infoField("Download", speedLimit(t.DownloadLimited, t.DownloadLimit))
+	infoField("Session", fmt.Sprintf("limits honored: %t", t.HonorsSessionLimits))
+
+	fmt.Println("  Dates")
+	infoField("Added", infoDate(t.AddedDate))
+	infoField("Activity", infoDate(t.ActivityDate))
+	infoField("Done", infoDate(t.DoneDate))
+
+	fmt.Println("  Origin")
+	infoField("Pieces", fmt.Sprintf("%d x %s", t.PieceCount, size(t.PieceSize)))
+	infoField("Private", fmt.Sprintf("%t", t.IsPrivate))
+	infoField("Created", fmt.Sprintf("%s by %s", infoDate(t.DateCreated), t.Creator))
+	if t.Comment != "" {
+		infoField("Comment", t.Comment)
+	}
+	infoField("Magnet", t.MagnetLink)
+
+	fmt.Println("  Swarm")
+	infoField("Trackers", strconv.Itoa(len(t.TrackerStats)))
+	infoField("Peers", fmt.Sprintf("%d connected, %d sending to us, %d getting from us",
+		t.PeersConnected, t.PeersSendingToUs, t.PeersGettingFromUs))
+}
+
+func infoField(name, value string) {
+	fmt.Printf("    %-11s %s\n", name+":", value)
+}
+
+func infoDate(t int64) string {
+	if t <= 0 {
+		return "never"
+	}
+	ago := "just now"
+	if d := myDurationSince(t); d != "" {
+		ago = strings.TrimSpace(d) + " ago"
+	}
+	return fmt.Sprintf("%s (%s)", time.Unix(t, 0).Format("2006-01-02 15:04:05"), ago)
+}
+
+// seedLimit describes a seed ratio or idle limit given its mode.
+func seedLimit(mode int, limit string) string {
+	switch mode {
+	case seedModeGlobal:
+		return "from session"
+	case seedModeSingle:
+		return limit
+	default:
+		return "unlimited"
+	}
+}
+
+func speedLimit(limited bool, limit int64) string {
+	if !limited {
+		return "unlimited"
 	}
+	return units.HumanSize(float64(limit*1000)) + "/s"
 }
 
 func init() {
@@ -0,0 +1,198 @@
+// Copyright © 2017 Charles Haynes <ceh@ceh.bz>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	units "github.com/docker/go-units"
+	"github.com/spf13/cobra"
+)
+
+// labelCmd represents the label command
+var labelCmd = &cobra.Command{
+	Use:   "label",
+	Short: "Change the labels of torrents",
+	Long: `Add, remove or set the labels of the selected torrents.
+
+Example:
+trr label add -t 3,7 linux iso`,
+}
+
+var labelAddCmd = &cobra.Command{
+	Use:   "add <label>...",
+	Short: "Add labels to torrents",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		editLabels(func(ls []string) []string { return addLabels(ls, args) })
+	},
+}
+
+var labelRemoveCmd = &cobra.Command{
+	Use:   "remove <label>...",
+	Short: "Remove labels from torrents",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		editLabels(func(ls []string) []string { return removeLabels(ls, args) })
+	},
+}
+
+var labelSetCmd = &cobra.Command{
+	Use:   "set [label]...",
+	Short: "Replace the labels of torrents",
+	Long:  `Replace the labels of the selected torrents. With no labels, clear them.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		editLabels(func([]string) []string { return addLabels(nil, args) })
+	},
+}
+
+// labelsCmd represents the labels command
+var labelsCmd = &cobra.Command{
+	Use:   "labels",
+	Short: "List labels",
+	Long:  `List the labels of the selected torrents with how many torrents have each, and their total size.`,
+	Args:  cobra.NoArgs,
+	Run:   doLabels,
+}
+
+// addLabels returns ls with the labels in add it doesn't have yet appended.
+func addLabels(ls, add []string) []string {
+	r := []string{}
+	for _, l := range ls {
+		if !containsString(r, l) {
+			r = append(r, l)
+		}
+	}
+	for _, l := range add {
+		if !containsString(r, l) {
+			r = append(r, l)
+		}
+	}
+	return r
+}
+
+// removeLabels returns ls without the labels in remove.
+func removeLabels(ls, remove []string) []string {
+	r := []string{}
+	for _, l := range ls {
+		if !containsString(remove, l) {
+			r = append(r, l)
+		}
+	}
+	return r
+}
+
+func containsString(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// editLabels sets the labels of each selected torrent to what edit returns
+// for its current labels, and shows the torrents that changed.
+func editLabels(edit func([]string) []string) {
+	x := getRPC()
+	ids, err := getTorrents()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	ts, err := x.torrentGet(ids, []string{"id", "name", "labels"})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, t := range ts {
+		ls := edit(t.Labels)
+		was, now := strings.Join(t.Labels, ","), strings.Join(ls, ",")
+		if was == now {
+			continue
+		}
+		if err := x.torrentSet([]int{t.ID}, map[string]interface{}{"labels": ls}); err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Printf("Torrent %d: %s\n", t.ID, t.Name)
+		fmt.Printf("  labels: %s -> %s\n", was, now)
+	}
+}
+
+// labelTotal is the number and total size of the torrents with a label.
+type labelTotal struct {
+	torrents int
+	size     int64
+}
+
+func doLabels(cmd *cobra.Command, args []string) {
+	x := getRPC()
+	ids, err := getTorrents()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	ts, err := x.torrentGet(ids, []string{"id", "labels", "sizeWhenDone"})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	totals := map[string]*labelTotal{}
+	var unlabelled labelTotal
+	for _, t := range ts {
+		if len(t.Labels) == 0 {
+			unlabelled.torrents++
+			unlabelled.size += t.SizeWhenDone
+		}
+		for _, l := range t.Labels {
+			lt, ok := totals[l]
+			if !ok {
+				lt = &labelTotal{}
+				totals[l] = lt
+			}
+			lt.torrents++
+			lt.size += t.SizeWhenDone
+		}
+	}
+	ls := []string{}
+	for l := range totals {
+		ls = append(ls, l)
+	}
+	sort.Strings(ls)
+	fmt.Println("Torrents      Size Label")
+	for _, l := range ls {
+		fmt.Printf("%8d %9s %s\n", totals[l].torrents, units.HumanSize(float64(totals[l].size)), l)
+	}
+	if unlabelled.torrents > 0 {
+		fmt.Printf("%8d %9s %s\n", unlabelled.torrents, units.HumanSize(float64(unlabelled.size)), "(none)")
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(labelCmd)
+	RootCmd.AddCommand(labelsCmd)
+	labelCmd.AddCommand(labelAddCmd)
+	labelCmd.AddCommand(labelRemoveCmd)
+	labelCmd.AddCommand(labelSetCmd)
+}
@@ -0,0 +1,132 @@
+// Copyright © 2017 Charles Haynes <ceh@ceh.bz>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// limitCmd represents the limit command
+var limitCmd = &cobra.Command{
+	Use:   "limit [up <rate>|off] [down <rate>|off] | off",
+	Short: "Set speed limits",
+	Long: `Set the session upload and download speed limits. Rates are kB/s, sizes
+per second such as 500K or 2M, or off to remove a limit. "limit off" removes
+both.
+When --torrents is given, the limits of those torrents are set instead of the
+session's. Each changed value is shown as old -> new.
+
+Example:
+trr limit up 500K down 2M
+trr limit -t 3,7 down off`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  doLimit,
+}
+
+// speedLimitFields are the fields for the limit and whether it is enabled,
+// for the session and for a torrent.
+var speedLimitFields = map[string]struct{ session, sessionEnabled, torrent, torrentEnabled string }{
+	"up":   {"speed-limit-up", "speed-limit-up-enabled", "uploadLimit", "uploadLimited"},
+	"down": {"speed-limit-down", "speed-limit-down-enabled", "downloadLimit", "downloadLimited"},
+}
+
+func doLimit(cmd *cobra.Command, args []string) {
+	perTorrent := cmd.Flags().Changed("torrents")
+	if len(args) == 1 && args[0] == "off" {
+		args = []string{"up", "off", "down", "off"}
+	}
+	if len(args)%2 != 0 {
+		fmt.Println("want pairs of up or down and a rate")
+		return
+	}
+	a := map[string]interface{}{}
+	keys := []string{}
+	for i := 0; i < len(args); i += 2 {
+		f, ok := speedLimitFields[args[i]]
+		if !ok {
+			fmt.Printf("%s: want up or down\n", args[i])
+			return
+		}
+		limit, enabled := f.session, f.sessionEnabled
+		if perTorrent {
+			limit, enabled = f.torrent, f.torrentEnabled
+		}
+		keys = append(keys, enabled)
+		if args[i+1] == "off" {
+			a[enabled] = false
+			continue
+		}
+		r, err := parseRate(args[i+1])
+		if err != nil {
+			fmt.Printf("%s: %s\n", args[i+1], err)
+			return
+		}
+		a[limit] = r
+		a[enabled] = true
+		keys = append(keys, limit)
+	}
+	x := getRPC()
+	if perTorrent {
+		ids, err := getTorrents()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		fields := append([]string{"id", "name"}, keys...)
+		before, err := x.torrentGetRaw(ids, fields)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if err = x.torrentSet(ids, a); err != nil {
+			fmt.Println(err)
+			return
+		}
+		after, err := x.torrentGetRaw(ids, fields)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		printTorrentDiff(before, after, keys)
+		return
+	}
+	before, err := x.sessionGet(keys...)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if err = x.sessionSet(a); err != nil {
+		fmt.Println(err)
+		return
+	}
+	after, err := x.sessionGet(keys...)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	printSessionDiff(before, after, keys)
+}
+
+func init() {
+	RootCmd.AddCommand(limitCmd)
+}
@@ -22,7 +22,6 @@ package cmd
 
 import (
 	"fmt"
-	"log"
 	"math"
 	"sort"
 	"strconv"
@@ -34,9 +33,9 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var sortBy string
+var sortBy, filterBy string
 
-type myTorrents transmission.Torrents
+type myTorrents []*rpcTorrent
 
 type sorter func(t myTorrents, i, j int) bool
 
@@ -56,7 +55,10 @@ For Example:
 trr list - list all torrents for default tracker
 trr list -sort active - list all torrents sorted by the time they were last active
 trr list -filter uploading -sort added,name - list uloading torrents sorted by
-    when they were added, and then by name`,
+    when they were added, and then by name
+trr list -sort queue - list all torrents in queue order
+trr list -filter label:linux,!stopped - list torrents labelled linux that
+    aren't stopped`,
 	Run: doList,
 }
 
@@ -70,29 +72,38 @@ func getServer() *transmission.TransmissionClient {
 	return x
 }
 
-func getTorrents() []int {
+// getTorrents returns the ids given with --torrents, nil meaning all.
+func getTorrents() ([]int, error) {
 	if torrents == "all" {
-		return nil
+		return nil, nil
 	}
 	idStrings := strings.Split(torrents, ",")
 	ids := make([]int, len(idStrings))
 	for i, id := range idStrings {
 		t, err := strconv.Atoi(id)
 		if err != nil {
-			log.Print(err)
-			return nil
+			return nil, fmt.Errorf("--torrents: %q is not a torrent id", id)
 		}
 		ids[i] = t
 	}
-	return ids
+	return ids, nil
 }
 
 func doList(cmd *cobra.Command, args []string) {
-	x := getServer()
-	c := transmission.NewGetTorrentsCmd()
-	c.Arguments.Ids = getTorrents()
-	c.Arguments.Fields = []string{
+	filter, err := parseFilter(filterBy)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	x := getRPC()
+	ids, err := getTorrents()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	ts, err := x.torrentGet(ids, append(filterFields,
 		"addedDate",
+		"downloadedEver",
 		"error",
 		"errorString",
 		"eta",
@@ -100,33 +111,44 @@ func doList(cmd *cobra.Command, args []string) {
 		"haveValid",
 		"id",
 		"isFinished",
+		"labels",
 		"leftUntilDone",
 		"name",
 		"peersGettingFromUs",
 		"peersSendingToUs",
 		"percentDone",
+		"queuePosition",
 		"rateDownload",
 		"rateUpload",
 		"sizeWhenDone",
 		"status",
 		"uploadRatio",
-	}
-	res, err := x.ExecuteCommand(c)
+		"uploadedEver",
+	))
 	if err != nil {
 		fmt.Println(err)
 		return
 	}
-	ts := res.Arguments.Torrents
+	ts = filterTorrents(ts, filter)
 	if sortBy != "" {
 		less = getSorter(sortBy)
 		sort.Sort(myTorrents(ts))
 	}
 	// ID     Done       Have  ETA           Up    Down  Ratio  Status       Name
 	//   11    16%   618.8 MB  Unknown      0.0     7.0    0.0  Up & Down    Leo Kottke
-	fmt.Println("   ID Done      Have       ETA      Up    Down Ratio Status        Name")
-	for _, t := range ts {
-		fmt.Printf("%5d %3.0f%% %9s %10s %8s %8s %5.1f %-13s %s\n",
+	labels := make([]string, len(ts))
+	w := len("Labels")
+	for i, t := range ts {
+		labels[i] = strings.Join(t.Labels, ",")
+		if len(labels[i]) > w {
+			w = len(labels[i])
+		}
+	}
+	fmt.Printf("   ID Queue Done      Have       ETA      Up    Down Ratio Status        %-*s Name\n", w, "Labels")
+	for i, t := range ts {
+		fmt.Printf("%5d %5d %3.0f%% %9s %10s %8s %8s %5.1f %-13s %-*s %s\n",
 			t.ID,
+			t.QueuePosition,
 			t.PercentDone*100.0,
 			units.HumanSize(float64(t.Have())),
 			myDuration(myETA(t)),
@@ -134,6 +156,7 @@ func doList(cmd *cobra.Command, args []string) {
 			units.HumanSize(float64(t.RateDownload)),
 			t.UploadRatio,
 			Status(t),
+			w, labels[i],
 			t.Name)
 		if err != nil {
 			fmt.Println(err)
@@ -183,6 +206,10 @@ func getSorter(s string) sorter {
 		return func(t myTorrents, i, j int) bool { return t[i].UploadRatio < t[j].UploadRatio }
 	case "-ratio":
 		return func(t myTorrents, i, j int) bool { return t[i].UploadRatio > t[j].UploadRatio }
+	case "queue":
+		return func(t myTorrents, i, j int) bool { return t[i].QueuePosition < t[j].QueuePosition }
+	case "-queue":
+		return func(t myTorrents, i, j int) bool { return t[i].QueuePosition > t[j].QueuePosition }
 	case "eta":
 		return func(t myTorrents, i, j int) bool { return myETA(t[i]) < myETA(t[j]) }
 	case "-eta":
@@ -192,7 +219,7 @@ func getSorter(s string) sorter {
 	}
 }
 
-func myETA(t *transmission.Torrent) int64 {
+func myETA(t *rpcTorrent) int64 {
 	switch true {
 	case t.LeftUntilDone == 0 || t.Eta == 0:
 		return 0
@@ -208,25 +235,39 @@ func myETA(t *transmission.Torrent) int64 {
 }
 
 // Status prints a human readable status for the torrent
-func Status(t *transmission.Torrent) string {
+func Status(t *rpcTorrent) string {
 	if t.ErrorString != "" {
 		return t.ErrorString
 	}
 	if t.Error != 0 {
 		return "Error"
 	}
+	return activity(t)
+}
+
+// statusCategory is activity, or error for any torrent with an error. Unlike
+// Status it has a fixed set of values.
+func statusCategory(t *rpcTorrent) string {
+	if t.Error != 0 || t.ErrorString != "" {
+		return "error"
+	}
+	return strings.ToLower(activity(t))
+}
+
+// activity is what Status shows for a torrent without an error.
+func activity(t *rpcTorrent) string {
 	switch t.Status {
-	case transmission.Stopped:
+	case statusStopped:
 		return "Stopped"
-	case transmission.CheckPending:
+	case statusCheckPending:
 		return "Wait Verify"
-	case transmission.Checking:
+	case statusChecking:
 		return "Verifying"
-	case transmission.DownloadPending:
+	case statusDownloadPending:
 		return "Wait Download"
-	case transmission.SeedPending:
+	case statusSeedPending:
 		return "Wait Seed"
-	case transmission.Seeding, transmission.Downloading:
+	case statusSeeding, statusDownloading:
 		switch true {
 		case t.RateDownload == 0 && t.RateUpload == 0:
 			return "Idle"
@@ -253,6 +294,7 @@ func init() {
 	// and all subcommands, e.g.:
 	// listCmd.PersistentFlags().String("foo", "", "A help for foo")
 	listCmd.PersistentFlags().StringVar(&sortBy, "sort", "", "what field to sort on")
+	listCmd.PersistentFlags().StringVar(&filterBy, "filter", "", "which torrents to list, e.g. label:linux,idle")
 
 	// Cobra supports local flags which will only run when this command
 	// is called directly, e.g.:
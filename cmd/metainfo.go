@@ -0,0 +1,248 @@
+// Copyright © 2017 Charles Haynes <ceh@ceh.bz>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/charles-haynes/trr/internal/bencode"
+)
+
+// metaFile is a file of a torrent. Its path is relative to the download
+// directory, the way transmission names files: the torrent name alone for a
+// single file torrent, otherwise the name followed by the file's path.
+type metaFile struct {
+	path   string
+	length int64
+	pad    bool
+}
+
+// metainfo is the content of a .torrent file.
+type metainfo struct {
+	name         string
+	infoHashV1   string
+	infoHashV2   string
+	pieceLength  int64
+	pieces       []byte
+	files        []metaFile
+	trackers     [][]string
+	webSeeds     []string
+	private      bool
+	source       string
+	comment      string
+	creator      string
+	creationDate int64
+}
+
+func loadMetainfo(path string) (*metainfo, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseMetainfo(data)
+}
+
+func parseMetainfo(data []byte) (*metainfo, error) {
+	v, err := bencode.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	top, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("torrent is not a dictionary")
+	}
+	info, ok := top["info"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("torrent has no info dictionary")
+	}
+	raw, err := bencode.RawValue(data, "info")
+	if err != nil {
+		return nil, err
+	}
+	m := &metainfo{
+		name:         bstring(info, "name"),
+		pieceLength:  bint(info, "piece length"),
+		pieces:       []byte(bstring(info, "pieces")),
+		private:      bint(info, "private") == 1,
+		source:       bstring(info, "source"),
+		comment:      bstring(top, "comment"),
+		creator:      bstring(top, "created by"),
+		creationDate: bint(top, "creation date"),
+	}
+	if m.name == "" {
+		return nil, errors.New("torrent has no name")
+	}
+	if m.pieceLength <= 0 {
+		return nil, errors.New("torrent has no piece length")
+	}
+	v2 := bint(info, "meta version") == 2
+	if len(m.pieces) == 0 && !v2 {
+		return nil, errors.New("torrent has no pieces")
+	}
+	if len(m.pieces)%sha1.Size != 0 {
+		return nil, errors.New("torrent pieces are not a whole number of hashes")
+	}
+	if len(m.pieces) > 0 {
+		h := sha1.Sum(raw)
+		m.infoHashV1 = hex.EncodeToString(h[:])
+	}
+	if v2 {
+		h := sha256.Sum256(raw)
+		m.infoHashV2 = hex.EncodeToString(h[:])
+	}
+
+	switch {
+	case info["files"] != nil:
+		fs, _ := info["files"].([]interface{})
+		for _, f := range fs {
+			fd, _ := f.(map[string]interface{})
+			ps := []string{m.name}
+			p, _ := fd["path"].([]interface{})
+			for _, e := range p {
+				s, _ := e.(string)
+				ps = append(ps, s)
+			}
+			m.files = append(m.files, metaFile{
+				path:   strings.Join(ps, "/"),
+				length: bint(fd, "length"),
+				pad:    strings.Contains(bstring(fd, "attr"), "p"),
+			})
+		}
+	case info["length"] != nil:
+		m.files = []metaFile{{path: m.name, length: bint(info, "length")}}
+	case info["file tree"] != nil:
+		tree, _ := info["file tree"].(map[string]interface{})
+		m.files = fileTree(nil, tree)
+		if len(m.files) != 1 || m.files[0].path != m.name {
+			for i := range m.files {
+				m.files[i].path = m.name + "/" + m.files[i].path
+			}
+		}
+	}
+	if len(m.files) == 0 {
+		return nil, errors.New("torrent has no files")
+	}
+
+	if a := bstring(top, "announce"); a != "" {
+		m.trackers = [][]string{{a}}
+	}
+	if al, ok := top["announce-list"].([]interface{}); ok && len(al) > 0 {
+		m.trackers = nil
+		for _, tier := range al {
+			ts, _ := tier.([]interface{})
+			us := []string{}
+			for _, u := range ts {
+				if s, ok := u.(string); ok {
+					us = append(us, s)
+				}
+			}
+			if len(us) > 0 {
+				m.trackers = append(m.trackers, us)
+			}
+		}
+	}
+	switch ws := top["url-list"].(type) {
+	case string:
+		m.webSeeds = []string{ws}
+	case []interface{}:
+		for _, u := range ws {
+			if s, ok := u.(string); ok {
+				m.webSeeds = append(m.webSeeds, s)
+			}
+		}
+	}
+	return m, nil
+}
+
+// fileTree flattens a v2 file tree, in the order of its sorted keys.
+func fileTree(prefix []string, tree map[string]interface{}) []metaFile {
+	names := []string{}
+	for n := range tree {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	fs := []metaFile{}
+	for _, n := range names {
+		node, _ := tree[n].(map[string]interface{})
+		if n == "" {
+			fs = append(fs, metaFile{path: strings.Join(prefix, "/"), length: bint(node, "length")})
+			continue
+		}
+		fs = append(fs, fileTree(append(prefix[:len(prefix):len(prefix)], n), node)...)
+	}
+	return fs
+}
+
+func bstring(m map[string]interface{}, k string) string {
+	s, _ := m[k].(string)
+	return s
+}
+
+func bint(m map[string]interface{}, k string) int64 {
+	n, _ := m[k].(int64)
+	return n
+}
+
+func (m *metainfo) totalSize() int64 {
+	var n int64
+	for _, f := range m.files {
+		n += f.length
+	}
+	return n
+}
+
+// pieceCount is the number of v1 pieces, or for v2 only torrents the number
+// of pieces over all files, as v2 pieces don't span files.
+func (m *metainfo) pieceCount() int {
+	if len(m.pieces) > 0 {
+		return len(m.pieces) / sha1.Size
+	}
+	n := 0
+	for _, f := range m.files {
+		n += int((f.length + m.pieceLength - 1) / m.pieceLength)
+	}
+	return n
+}
+
+// magnet builds a magnet link for the torrent.
+func (m *metainfo) magnet() string {
+	q := []string{}
+	if m.infoHashV1 != "" {
+		q = append(q, "xt=urn:btih:"+m.infoHashV1)
+	}
+	if m.infoHashV2 != "" {
+		q = append(q, "xt=urn:btmh:1220"+m.infoHashV2)
+	}
+	q = append(q, "dn="+url.QueryEscape(m.name))
+	for _, tier := range m.trackers {
+		for _, u := range tier {
+			q = append(q, "tr="+url.QueryEscape(u))
+		}
+	}
+	return "magnet:?" + strings.Join(q, "&")
+}
@@ -22,44 +22,210 @@ package cmd
 
 import (
 	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
 
-	"github.com/charles-haynes/transmission"
+	units "github.com/docker/go-units"
 	"github.com/spf13/cobra"
 )
 
+var peersSummary string
+var peersVerbose bool
+
 // peersCmd represents the peers command
 var peersCmd = &cobra.Command{
 	Use:   "peers",
 	Short: "Info about the peers for a torrent",
-	Long:  `For each torrent display detailed peer information`,
-	Run:   doInfoPeers,
+	Long: `For each torrent display detailed peer information
+
+With --verbose, each peer's flags are spelled out on the line below it.
+
+With --summary, group the peers of each torrent instead of listing them:
+  client - by client name
+  flags  - by connection flags, a peer counts once for each of its flags
+  rate   - by how fast data flows to and from the peer`,
+	Run: doInfoPeers,
+}
+
+// peerFlagNames describes the characters of a peer's flagStr.
+var peerFlagNames = map[rune]string{
+	'O': "optimistic unchoke",
+	'D': "downloading from peer",
+	'd': "would download from peer",
+	'U': "uploading to peer",
+	'u': "would upload to peer",
+	'K': "peer unchoked us, not interested",
+	'?': "we unchoked peer, not interested",
+	'E': "encrypted",
+	'H': "from DHT",
+	'X': "from PEX",
+	'L': "from LPD",
+	'I': "incoming",
+	'T': "uTP",
+}
+
+// flagWords decodes a peer's flagStr.
+func flagWords(flags string) []string {
+	fs := []string{}
+	for _, c := range flags {
+		if n, ok := peerFlagNames[c]; ok {
+			fs = append(fs, n)
+		}
+	}
+	return fs
+}
+
+// peerFlags returns the decoded flags of p, including its choke and interest
+// state.
+func peerFlags(p rpcPeer) []string {
+	fs := flagWords(p.FlagStr)
+	if p.ClientIsChoked {
+		fs = append(fs, "choked by peer")
+	}
+	if p.PeerIsChoked {
+		fs = append(fs, "peer choked by us")
+	}
+	if p.ClientIsInterested {
+		fs = append(fs, "interested in peer")
+	}
+	if p.PeerIsInterested {
+		fs = append(fs, "peer interested in us")
+	}
+	return fs
+}
+
+// peerRateBuckets are the upper bounds, in bytes/sec, of the rate summary
+// groups.
+var peerRateBuckets = []int64{1, 10 * 1000, 100 * 1000, 1000 * 1000}
+
+// peerRateBucket names the group for the combined rate of p.
+func peerRateBucket(p rpcPeer) string {
+	r := p.RateToClient + p.RateToPeer
+	if r < peerRateBuckets[0] {
+		return "idle"
+	}
+	for i, b := range peerRateBuckets[1:] {
+		if r < b {
+			return fmt.Sprintf("%s - %s/s",
+				units.HumanSize(float64(peerRateBuckets[i])),
+				units.HumanSize(float64(b)))
+		}
+	}
+	return fmt.Sprintf("over %s/s", units.HumanSize(float64(peerRateBuckets[len(peerRateBuckets)-1])))
+}
+
+// peerGroup totals the peers sharing a key.
+type peerGroup struct {
+	key   string
+	count int
+	down  int64
+	up    int64
+}
+
+// groupPeers groups ps by the keys returned by keys, largest groups first.
+func groupPeers(ps []rpcPeer, keys func(rpcPeer) []string) []*peerGroup {
+	m := map[string]*peerGroup{}
+	for _, p := range ps {
+		for _, k := range keys(p) {
+			g, ok := m[k]
+			if !ok {
+				g = &peerGroup{key: k}
+				m[k] = g
+			}
+			g.count++
+			g.down += p.RateToClient
+			g.up += p.RateToPeer
+		}
+	}
+	gs := []*peerGroup{}
+	for _, g := range m {
+		gs = append(gs, g)
+	}
+	sort.Slice(gs, func(i, j int) bool {
+		if gs[i].count != gs[j].count {
+			return gs[i].count > gs[j].count
+		}
+		return gs[i].key < gs[j].key
+	})
+	return gs
+}
+
+// peerSummaryKeys returns the grouping for a --summary mode.
+func peerSummaryKeys(mode string) (func(rpcPeer) []string, error) {
+	switch mode {
+	case "client":
+		return func(p rpcPeer) []string { return []string{p.ClientName} }, nil
+	case "flags":
+		return peerFlags, nil
+	case "rate":
+		return func(p rpcPeer) []string { return []string{peerRateBucket(p)} }, nil
+	default:
+		return nil, fmt.Errorf("unknown summary %q, want client, flags or rate", mode)
+	}
 }
 
 func doInfoPeers(cmd *cobra.Command, args []string) {
-	x := getServer()
-	c := transmission.NewGetTorrentsCmd()
-	c.Arguments.Ids = getTorrents()
-	c.Arguments.Fields = []string{"peers", "id", "name"}
-	res, err := x.ExecuteCommand(c)
+	var keys func(rpcPeer) []string
+	if peersSummary != "" {
+		var err error
+		if keys, err = peerSummaryKeys(peersSummary); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+	x := getRPC()
+	ids, err := getTorrents()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	ts, err := x.torrentGet(ids, []string{"peers", "id", "name"})
 	if err != nil {
 		fmt.Println(err)
 		return
 	}
-	for _, t := range res.Arguments.Torrents {
+	for _, t := range ts {
 		fmt.Printf("Torrent %d: %s\n", t.ID, t.Name)
-		fmt.Println("Address         Flags   Done   Down     Up Client")
-		for _, p := range t.Peers {
-			fmt.Printf("%-15s %5s %5.1f%% %6.1f %6.1f %s\n",
-				p.Address,
-				p.Flags,
+		if keys != nil {
+			fmt.Println("Peers      Down        Up Group")
+			for _, g := range groupPeers(t.Peers, keys) {
+				fmt.Printf("%5d %9s %9s %s\n",
+					g.count,
+					units.HumanSize(float64(g.down)),
+					units.HumanSize(float64(g.up)),
+					g.key)
+			}
+			continue
+		}
+		addrs := make([]string, len(t.Peers))
+		w := len("Address")
+		for i, p := range t.Peers {
+			addrs[i] = net.JoinHostPort(p.Address, strconv.Itoa(p.Port))
+			if len(addrs[i]) > w {
+				w = len(addrs[i])
+			}
+		}
+		fmt.Printf("%-*s Flags   Done     Down       Up Client\n", w, "Address")
+		for i, p := range t.Peers {
+			fmt.Printf("%-*s %5s %5.1f%% %8s %8s %s\n",
+				w, addrs[i],
+				p.FlagStr,
 				p.Progress*100.0,
-				float64(p.RateToClient)/1000.0,
-				float64(p.RateToPeer)/1000.0,
+				units.HumanSize(float64(p.RateToClient)),
+				units.HumanSize(float64(p.RateToPeer)),
 				p.ClientName)
+			if peersVerbose {
+				fmt.Printf("    %s\n", strings.Join(flagWords(p.FlagStr), ", "))
+			}
 		}
 	}
 }
 
 func init() {
 	infoCmd.AddCommand(peersCmd)
+
+	peersCmd.Flags().BoolVarP(&peersVerbose, "verbose", "v", false, "spell out peer flags")
+	peersCmd.Flags().StringVar(&peersSummary, "summary", "", "group peers by client, flags or rate")
 }
@@ -0,0 +1,112 @@
+// Copyright © 2017 Charles Haynes <ceh@ceh.bz>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	units "github.com/docker/go-units"
+	"github.com/spf13/cobra"
+)
+
+var peerTopLimit int
+
+// peerCmd represents the peers command
+var peerCmd = &cobra.Command{
+	Use:   "peers",
+	Short: "Peers across torrents",
+	Long:  `Commands that look at the peers of all the selected torrents together.`,
+}
+
+// peerTopCmd represents the peers top command
+var peerTopCmd = &cobra.Command{
+	Use:   "top",
+	Short: "Busiest peers across torrents",
+	Long: `Rank the peers of the selected torrents by the total bytes/sec flowing to
+and from them, adding up each peer's rates over all the torrents it shares
+with us.`,
+	Args: cobra.NoArgs,
+	Run:  doPeerTop,
+}
+
+// peerTotal adds up the rates of one peer address across torrents.
+type peerTotal struct {
+	address  string
+	client   string
+	torrents int
+	down     int64
+	up       int64
+}
+
+func doPeerTop(cmd *cobra.Command, args []string) {
+	x := getRPC()
+	ids, err := getTorrents()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	ts, err := x.torrentGet(ids, []string{"peers", "id"})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	m := map[string]*peerTotal{}
+	for _, t := range ts {
+		for _, p := range t.Peers {
+			pt, ok := m[p.Address]
+			if !ok {
+				pt = &peerTotal{address: p.Address, client: p.ClientName}
+				m[p.Address] = pt
+			}
+			pt.torrents++
+			pt.down += p.RateToClient
+			pt.up += p.RateToPeer
+		}
+	}
+	pts := []*peerTotal{}
+	for _, pt := range m {
+		pts = append(pts, pt)
+	}
+	sort.Slice(pts, func(i, j int) bool {
+		return pts[i].down+pts[i].up > pts[j].down+pts[j].up
+	})
+	if peerTopLimit > 0 && len(pts) > peerTopLimit {
+		pts = pts[:peerTopLimit]
+	}
+	fmt.Println("    Total      Down        Up Torrents Address                                  Client")
+	for _, pt := range pts {
+		fmt.Printf("%9s %9s %9s %8d %-40s %s\n",
+			units.HumanSize(float64(pt.down+pt.up)),
+			units.HumanSize(float64(pt.down)),
+			units.HumanSize(float64(pt.up)),
+			pt.torrents,
+			pt.address,
+			pt.client)
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(peerCmd)
+	peerCmd.AddCommand(peerTopCmd)
+
+	peerTopCmd.Flags().IntVar(&peerTopLimit, "limit", 20, "how many peers to show, 0 for all")
+}
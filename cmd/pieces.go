@@ -0,0 +1,150 @@
+// Copyright © 2017 Charles Haynes <ceh@ceh.bz>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	units "github.com/docker/go-units"
+	"github.com/spf13/cobra"
+)
+
+var piecesWidth, piecesRows int
+
+// piecesCmd represents the pieces command
+var piecesCmd = &cobra.Command{
+	Use:   "pieces",
+	Short: "Map of the pieces of a torrent",
+	Long: `For each torrent draw a map of the pieces we have. Each cell stands for
+one or more pieces and is shaded by how many of them we have.
+
+Cells with none of their pieces are marked by whether a connected peer can
+supply them, when the daemon reports per piece availability. Otherwise they
+are all drawn as -, and a single estimate from the peers' progress is shown
+instead: a seed has every piece, and the partial peers' progress is added up.
+
+  █ all   ▓ most   ▒ half   ░ some   · none, a peer has them   ? none, no peer has them
+  - none, availability unknown`,
+	Run: doInfoPieces,
+}
+
+var pieceShades = []rune{'░', '▒', '▓', '█'}
+
+func doInfoPieces(cmd *cobra.Command, args []string) {
+	x := getRPC()
+	ids, err := getTorrents()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	ts, err := x.torrentGet(ids, []string{"id", "name", "pieces", "pieceCount", "pieceSize", "peers", "availability"})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, t := range ts {
+		fmt.Printf("Torrent %d: %s\n", t.ID, t.Name)
+		bits, err := base64.StdEncoding.DecodeString(t.Pieces)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		have := func(i int) bool { return i/8 < len(bits) && bits[i/8]&(0x80>>uint(i%8)) != 0 }
+		n := 0
+		for i := 0; i < t.PieceCount; i++ {
+			if have(i) {
+				n++
+			}
+		}
+		done := 0.0
+		if t.PieceCount > 0 {
+			done = 100 * float64(n) / float64(t.PieceCount)
+		}
+		fmt.Printf("  %d pieces of %s, have %d (%.1f%%)\n",
+			t.PieceCount, units.HumanSize(float64(t.PieceSize)), n, done)
+		seeds, partial, best := 0, 0.0, 0.0
+		for _, p := range t.Peers {
+			if p.Progress >= 1 {
+				seeds++
+				continue
+			}
+			partial += p.Progress
+			if p.Progress > best {
+				best = p.Progress
+			}
+		}
+		fmt.Printf("  %d peers, %d seeds, best partial peer %.1f%%\n", len(t.Peers), seeds, best*100)
+		// copies is how many connected peers have piece i.
+		var copies func(i int) int
+		if len(t.Availability) == t.PieceCount {
+			copies = func(i int) int { return t.Availability[i] }
+		} else {
+			fmt.Printf("  no per piece availability, peers hold an estimated %.1f copies of each piece\n", float64(seeds)+partial)
+		}
+		printPieceMap(t.PieceCount, have, copies)
+	}
+}
+
+// printPieceMap draws count pieces in at most piecesRows lines of piecesWidth
+// cells. copies may be nil when piece availability is unknown.
+func printPieceMap(count int, have func(int) bool, copies func(int) int) {
+	cells := piecesWidth * piecesRows
+	if count < cells {
+		cells = count
+	}
+	if cells <= 0 {
+		return
+	}
+	line := []rune{}
+	for c := 0; c < cells; c++ {
+		from, to := c*count/cells, (c+1)*count/cells
+		n, avail := 0, false
+		for i := from; i < to; i++ {
+			if have(i) {
+				n++
+			} else if copies != nil && copies(i) >= 1 {
+				avail = true
+			}
+		}
+		switch {
+		case n > 0:
+			line = append(line, pieceShades[(n*len(pieceShades)-1)/(to-from)])
+		case copies == nil:
+			line = append(line, '-')
+		case avail:
+			line = append(line, '·')
+		default:
+			line = append(line, '?')
+		}
+		if len(line) == piecesWidth || c == cells-1 {
+			fmt.Printf("  %s\n", string(line))
+			line = line[:0]
+		}
+	}
+}
+
+func init() {
+	infoCmd.AddCommand(piecesCmd)
+
+	piecesCmd.Flags().IntVar(&piecesWidth, "width", 64, "cells per line")
+	piecesCmd.Flags().IntVar(&piecesRows, "rows", 8, "most lines to draw")
+}
@@ -0,0 +1,53 @@
+// Copyright © 2017 Charles Haynes <ceh@ceh.bz>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// portTestCmd represents the port-test command
+var portTestCmd = &cobra.Command{
+	Use:   "port-test",
+	Short: "Test whether the peer port is open",
+	Long:  `Ask the server to check whether its peer port can be reached from outside.`,
+	Args:  cobra.NoArgs,
+	Run:   doPortTest,
+}
+
+func doPortTest(cmd *cobra.Command, args []string) {
+	open, err := getRPC().portTest()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if open {
+		fmt.Println("Port is open")
+		return
+	}
+	fmt.Println("Port is closed")
+}
+
+func init() {
+	RootCmd.AddCommand(portTestCmd)
+}
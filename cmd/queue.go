@@ -0,0 +1,124 @@
+// Copyright © 2017 Charles Haynes <ceh@ceh.bz>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+// queueCmd represents the queue command
+var queueCmd = &cobra.Command{
+	Use:   "queue",
+	Short: "Show and change the download and seed queues",
+	Long: `Move the selected torrents within the queue, or show the queues.
+
+Example:
+trr queue top -t 12,14`,
+}
+
+var queueShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "List the download and seed queues in order",
+	Args:  cobra.NoArgs,
+	Run:   doQueueShow,
+}
+
+// queueMoveCmd returns the command that moves the selected torrents in the
+// direction given by the queue-move-<dir> RPC.
+func queueMoveCmd(dir, short string) *cobra.Command {
+	return &cobra.Command{
+		Use:   dir,
+		Short: short,
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := queueMove(dir); err != nil {
+				fmt.Println(err)
+			}
+		},
+	}
+}
+
+func queueMove(dir string) error {
+	ids, err := getTorrents()
+	if err != nil {
+		return err
+	}
+	if ids == nil {
+		return errors.New("select the torrents to move with --torrents")
+	}
+	return getRPC().call("queue-move-"+dir, map[string]interface{}{"ids": ids}, nil)
+}
+
+func doQueueShow(cmd *cobra.Command, args []string) {
+	x := getRPC()
+	s, err := x.sessionGet("download-queue-enabled", "download-queue-size", "seed-queue-enabled", "seed-queue-size")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	ids, err := getTorrents()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	ts, err := x.torrentGet(ids, []string{"id", "name", "queuePosition", "status", "error", "errorString", "rateDownload", "rateUpload"})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	sort.Slice(ts, func(i, j int) bool { return ts[i].QueuePosition < ts[j].QueuePosition })
+	var down, seed myTorrents
+	for _, t := range ts {
+		switch t.Status {
+		case statusDownloadPending, statusDownloading:
+			down = append(down, t)
+		case statusSeedPending, statusSeeding:
+			seed = append(seed, t)
+		}
+	}
+	printQueue("Download", down, s["download-queue-enabled"], s["download-queue-size"])
+	printQueue("Seed", seed, s["seed-queue-enabled"], s["seed-queue-size"])
+}
+
+func printQueue(name string, ts myTorrents, enabled, size interface{}) {
+	limit := "no limit"
+	if on, _ := enabled.(bool); on {
+		limit = fmt.Sprintf("%s active at most", formatValue(size))
+	}
+	fmt.Printf("%s queue (%d torrents, %s):\n", name, len(ts), limit)
+	fmt.Println("Queue    ID Status        Name")
+	for _, t := range ts {
+		fmt.Printf("%5d %5d %-13s %s\n", t.QueuePosition, t.ID, Status(t), t.Name)
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(queueCmd)
+	queueCmd.AddCommand(queueShowCmd)
+	queueCmd.AddCommand(queueMoveCmd("top", "Move torrents to the top of the queue"))
+	queueCmd.AddCommand(queueMoveCmd("up", "Move torrents up the queue"))
+	queueCmd.AddCommand(queueMoveCmd("down", "Move torrents down the queue"))
+	queueCmd.AddCommand(queueMoveCmd("bottom", "Move torrents to the bottom of the queue"))
+}
@@ -97,4 +97,7 @@ func initConfig() {
 	if err := viper.ReadInConfig(); err == nil {
 		fmt.Println("Using config file:", viper.ConfigFileUsed())
 	}
+	// The server flag only sets server when given; take the config file and
+	// the default into account too.
+	server = viper.GetString("server")
 }
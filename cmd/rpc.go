@@ -0,0 +1,427 @@
+// Copyright © 2017 Charles Haynes <ceh@ceh.bz>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const sessionIDHeader = "X-Transmission-Session-Id"
+
+// rpcClient talks to the transmission RPC endpoint directly. It covers the
+// methods and fields the transmission package doesn't know about, such as
+// torrent-set. It is safe for concurrent use.
+type rpcClient struct {
+	url    string
+	user   string
+	pass   string
+	client *http.Client
+
+	mu        sync.Mutex // guards sessionID
+	sessionID string
+}
+
+type rpcRequest struct {
+	Method    string      `json:"method"`
+	Arguments interface{} `json:"arguments,omitempty"`
+}
+
+type rpcResponse struct {
+	Result    string          `json:"result"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+func getRPC() *rpcClient {
+	return newRPC(fmt.Sprintf("http://%s/transmission/rpc", server), user, pass)
+}
+
+// newRPC returns a client for the RPC endpoint at url.
+func newRPC(url, user, pass string) *rpcClient {
+	return &rpcClient{
+		url:    url,
+		user:   user,
+		pass:   pass,
+		client: &http.Client{},
+	}
+}
+
+// call invokes method with args and decodes the response arguments into
+// result, which may be nil. A 409 from the daemon hands us a new session id,
+// so the request is retried once with it.
+func (c *rpcClient) call(method string, args, result interface{}) error {
+	body, err := json.Marshal(rpcRequest{Method: method, Arguments: args})
+	if err != nil {
+		return err
+	}
+	for retry := 0; retry < 2; retry++ {
+		req, err := http.NewRequest("POST", c.url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if id := c.session(); id != "" {
+			req.Header.Set(sessionIDHeader, id)
+		}
+		if c.user != "" {
+			req.SetBasicAuth(c.user, c.pass)
+		}
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode == http.StatusConflict {
+			c.setSession(resp.Header.Get(sessionIDHeader))
+			resp.Body.Close()
+			continue
+		}
+		var r rpcResponse
+		err = json.NewDecoder(resp.Body).Decode(&r)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("%s: %s", method, resp.Status)
+		}
+		if err != nil {
+			return err
+		}
+		if r.Result != "success" {
+			return errors.New(r.Result)
+		}
+		if result == nil || len(r.Arguments) == 0 {
+			return nil
+		}
+		return json.Unmarshal(r.Arguments, result)
+	}
+	return fmt.Errorf("%s: no session id from server", method)
+}
+
+func (c *rpcClient) session() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sessionID
+}
+
+func (c *rpcClient) setSession(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sessionID = id
+}
+
+// formatValue formats a value decoded from an RPC response. JSON numbers
+// decode as float64, which %v would show as 1e+06.
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []interface{}:
+		s := make([]string, len(v))
+		for i, e := range v {
+			s[i] = formatValue(e)
+		}
+		return "[" + strings.Join(s, " ") + "]"
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// rpcTracker is an entry of the torrent-get trackers field.
+type rpcTracker struct {
+	ID       int    `json:"id"`
+	Announce string `json:"announce"`
+	Scrape   string `json:"scrape"`
+	Tier     int    `json:"tier"`
+}
+
+// rpcTrackerStat is an entry of the torrent-get trackerStats field.
+type rpcTrackerStat struct {
+	ID                    int    `json:"id"`
+	Announce              string `json:"announce"`
+	AnnounceState         int    `json:"announceState"`
+	DownloadCount         int    `json:"downloadCount"`
+	HasAnnounced          bool   `json:"hasAnnounced"`
+	HasScraped            bool   `json:"hasScraped"`
+	Host                  string `json:"host"`
+	IsBackup              bool   `json:"isBackup"`
+	LastAnnouncePeerCount int    `json:"lastAnnouncePeerCount"`
+	LastAnnounceResult    string `json:"lastAnnounceResult"`
+	LastAnnounceSucceeded bool   `json:"lastAnnounceSucceeded"`
+	LastAnnounceTime      int64  `json:"lastAnnounceTime"`
+	LastScrapeResult      string `json:"lastScrapeResult"`
+	LastScrapeSucceeded   bool   `json:"lastScrapeSucceeded"`
+	LastScrapeTime        int64  `json:"lastScrapeTime"`
+	LeecherCount          int    `json:"leecherCount"`
+	NextAnnounceTime      int64  `json:"nextAnnounceTime"`
+	NextScrapeTime        int64  `json:"nextScrapeTime"`
+	ScrapeState           int    `json:"scrapeState"`
+	SeederCount           int    `json:"seederCount"`
+	Tier                  int    `json:"tier"`
+}
+
+// rpcFile is an entry of the torrent-get files field.
+type rpcFile struct {
+	Name           string `json:"name"`
+	Length         int64  `json:"length"`
+	BytesCompleted int64  `json:"bytesCompleted"`
+}
+
+// rpcPeer is an entry of the torrent-get peers field.
+type rpcPeer struct {
+	Address            string  `json:"address"`
+	ClientName         string  `json:"clientName"`
+	ClientIsChoked     bool    `json:"clientIsChoked"`
+	ClientIsInterested bool    `json:"clientIsInterested"`
+	FlagStr            string  `json:"flagStr"`
+	IsDownloadingFrom  bool    `json:"isDownloadingFrom"`
+	IsEncrypted        bool    `json:"isEncrypted"`
+	IsIncoming         bool    `json:"isIncoming"`
+	IsUploadingTo      bool    `json:"isUploadingTo"`
+	IsUTP              bool    `json:"isUTP"`
+	PeerIsChoked       bool    `json:"peerIsChoked"`
+	PeerIsInterested   bool    `json:"peerIsInterested"`
+	Port               int     `json:"port"`
+	Progress           float64 `json:"progress"`
+	RateToClient       int64   `json:"rateToClient"`
+	RateToPeer         int64   `json:"rateToPeer"`
+}
+
+// torrent status values
+const (
+	statusStopped = iota
+	statusCheckPending
+	statusChecking
+	statusDownloadPending
+	statusDownloading
+	statusSeedPending
+	statusSeeding
+)
+
+// rpcTorrent is a torrent as returned by torrent-get. Only the requested
+// fields are filled in.
+type rpcTorrent struct {
+	ID                  int              `json:"id"`
+	Name                string           `json:"name"`
+	ActivityDate        int64            `json:"activityDate"`
+	AddedDate           int64            `json:"addedDate"`
+	Availability        []int            `json:"availability"`
+	Comment             string           `json:"comment"`
+	CorruptEver         int64            `json:"corruptEver"`
+	Creator             string           `json:"creator"`
+	DateCreated         int64            `json:"dateCreated"`
+	DoneDate            int64            `json:"doneDate"`
+	DownloadDir         string           `json:"downloadDir"`
+	DownloadLimit       int64            `json:"downloadLimit"`
+	DownloadLimited     bool             `json:"downloadLimited"`
+	DownloadedEver      int64            `json:"downloadedEver"`
+	Error               int              `json:"error"`
+	ErrorString         string           `json:"errorString"`
+	Eta                 int64            `json:"eta"`
+	HashString          string           `json:"hashString"`
+	HaveUnchecked       int64            `json:"haveUnchecked"`
+	HaveValid           int64            `json:"haveValid"`
+	HonorsSessionLimits bool             `json:"honorsSessionLimits"`
+	IsFinished          bool             `json:"isFinished"`
+	IsPrivate           bool             `json:"isPrivate"`
+	Labels              []string         `json:"labels"`
+	LeftUntilDone       int64            `json:"leftUntilDone"`
+	MagnetLink          string           `json:"magnetLink"`
+	PeersConnected      int              `json:"peersConnected"`
+	PeersGettingFromUs  int              `json:"peersGettingFromUs"`
+	PeersSendingToUs    int              `json:"peersSendingToUs"`
+	PercentDone         float64          `json:"percentDone"`
+	PieceCount          int              `json:"pieceCount"`
+	Pieces              string           `json:"pieces"`
+	PieceSize           int64            `json:"pieceSize"`
+	QueuePosition       int              `json:"queuePosition"`
+	RateDownload        int64            `json:"rateDownload"`
+	RateUpload          int64            `json:"rateUpload"`
+	SecondsSeeding      int64            `json:"secondsSeeding"`
+	SeedIdleLimit       int              `json:"seedIdleLimit"`
+	SeedIdleMode        int              `json:"seedIdleMode"`
+	SeedRatioLimit      float64          `json:"seedRatioLimit"`
+	SeedRatioMode       int              `json:"seedRatioMode"`
+	SizeWhenDone        int64            `json:"sizeWhenDone"`
+	Status              int              `json:"status"`
+	TorrentFile         string           `json:"torrentFile"`
+	TotalSize           int64            `json:"totalSize"`
+	UploadLimit         int64            `json:"uploadLimit"`
+	UploadLimited       bool             `json:"uploadLimited"`
+	UploadRatio         float64          `json:"uploadRatio"`
+	UploadedEver        int64            `json:"uploadedEver"`
+	Files               []rpcFile        `json:"files"`
+	Peers               []rpcPeer        `json:"peers"`
+	Trackers            []rpcTracker     `json:"trackers"`
+	TrackerStats        []rpcTrackerStat `json:"trackerStats"`
+}
+
+// Have is the number of bytes of the torrent we have, checked or not.
+func (t *rpcTorrent) Have() int64 {
+	return t.HaveValid + t.HaveUnchecked
+}
+
+// torrentGet fetches fields for ids (nil means all torrents). Fields asked
+// for more than once are only requested once.
+func (c *rpcClient) torrentGet(ids []int, fields []string) ([]*rpcTorrent, error) {
+	seen := map[string]bool{}
+	fs := []string{}
+	for _, f := range fields {
+		if !seen[f] {
+			seen[f] = true
+			fs = append(fs, f)
+		}
+	}
+	args := map[string]interface{}{"fields": fs}
+	if ids != nil {
+		args["ids"] = ids
+	}
+	var res struct {
+		Torrents []*rpcTorrent `json:"torrents"`
+	}
+	err := c.call("torrent-get", args, &res)
+	return res.Torrents, err
+}
+
+// torrentGetRaw fetches fields for ids (nil means all torrents), keeping each
+// torrent as a generic map keyed by field name.
+func (c *rpcClient) torrentGetRaw(ids []int, fields []string) ([]map[string]interface{}, error) {
+	args := map[string]interface{}{"fields": fields}
+	if ids != nil {
+		args["ids"] = ids
+	}
+	var res struct {
+		Torrents []map[string]interface{} `json:"torrents"`
+	}
+	err := c.call("torrent-get", args, &res)
+	return res.Torrents, err
+}
+
+// torrentSet applies the torrent-set arguments in args to ids (nil means all
+// torrents).
+func (c *rpcClient) torrentSet(ids []int, args map[string]interface{}) error {
+	if ids != nil {
+		args["ids"] = ids
+	}
+	return c.call("torrent-set", args, nil)
+}
+
+// rpcVersion returns the RPC version the daemon speaks.
+func (c *rpcClient) rpcVersion() (int, error) {
+	s, err := c.sessionGet("rpc-version")
+	if err != nil {
+		return 0, err
+	}
+	v, _ := s["rpc-version"].(float64)
+	return int(v), nil
+}
+
+// sessionGet returns the session settings. With no fields the daemon
+// returns all of them.
+func (c *rpcClient) sessionGet(fields ...string) (map[string]interface{}, error) {
+	var args interface{}
+	if len(fields) > 0 {
+		args = map[string]interface{}{"fields": fields}
+	}
+	s := map[string]interface{}{}
+	err := c.call("session-get", args, &s)
+	return s, err
+}
+
+// sessionSet changes the session settings in args.
+func (c *rpcClient) sessionSet(args map[string]interface{}) error {
+	return c.call("session-set", args, nil)
+}
+
+// rpcStats is the transfer totals part of session-stats.
+type rpcStats struct {
+	UploadedBytes   int64 `json:"uploadedBytes"`
+	DownloadedBytes int64 `json:"downloadedBytes"`
+	FilesAdded      int64 `json:"filesAdded"`
+	SessionCount    int64 `json:"sessionCount"`
+	SecondsActive   int64 `json:"secondsActive"`
+}
+
+// rpcSessionStats is the result of session-stats.
+type rpcSessionStats struct {
+	ActiveTorrentCount int      `json:"activeTorrentCount"`
+	PausedTorrentCount int      `json:"pausedTorrentCount"`
+	TorrentCount       int      `json:"torrentCount"`
+	DownloadSpeed      int64    `json:"downloadSpeed"`
+	UploadSpeed        int64    `json:"uploadSpeed"`
+	Cumulative         rpcStats `json:"cumulative-stats"`
+	Current            rpcStats `json:"current-stats"`
+}
+
+func (c *rpcClient) sessionStats() (*rpcSessionStats, error) {
+	s := &rpcSessionStats{}
+	err := c.call("session-stats", nil, s)
+	return s, err
+}
+
+// freeSpace returns the bytes available at path on the daemon's host.
+func (c *rpcClient) freeSpace(path string) (int64, error) {
+	var res struct {
+		SizeBytes int64 `json:"size-bytes"`
+	}
+	err := c.call("free-space", map[string]interface{}{"path": path}, &res)
+	return res.SizeBytes, err
+}
+
+// portTest asks the daemon whether its peer port is reachable from outside.
+func (c *rpcClient) portTest() (bool, error) {
+	var res struct {
+		PortIsOpen bool `json:"port-is-open"`
+	}
+	err := c.call("port-test", nil, &res)
+	return res.PortIsOpen, err
+}
+
+// blocklistUpdate reloads the blocklist and returns how many rules it has.
+func (c *rpcClient) blocklistUpdate() (int, error) {
+	var res struct {
+		BlocklistSize int `json:"blocklist-size"`
+	}
+	err := c.call("blocklist-update", nil, &res)
+	return res.BlocklistSize, err
+}
+
+// torrentAdd adds a torrent. It returns the torrent's id, name and hash, and
+// whether the server already had it.
+func (c *rpcClient) torrentAdd(args map[string]interface{}) (*rpcTorrent, bool, error) {
+	var res struct {
+		Added     *rpcTorrent `json:"torrent-added"`
+		Duplicate *rpcTorrent `json:"torrent-duplicate"`
+	}
+	if err := c.call("torrent-add", args, &res); err != nil {
+		return nil, false, err
+	}
+	if res.Duplicate != nil {
+		return res.Duplicate, true, nil
+	}
+	if res.Added == nil {
+		return nil, false, errors.New("torrent-add: no torrent in response")
+	}
+	return res.Added, false, nil
+}
@@ -0,0 +1,347 @@
+// Copyright © 2017 Charles Haynes <ceh@ceh.bz>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var rulesDryRun bool
+
+// rulesCmd represents the rules command
+var rulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "Automated torrent housekeeping",
+	Long: `Run housekeeping rules from the config file. Each rule has a match, a filter
+as taken by list --filter, and actions applied in order to every torrent it
+matches:
+
+  stop                  stop the torrent
+  reannounce            ask the trackers for more peers
+  remove                remove the torrent, keeping its data
+  remove-with-data      remove the torrent and its data
+  move <dir>            move the torrent's data to dir
+  label <label>...      add labels
+  set <flag>=<value>... change settings, using the flags of trr set
+
+A rule with only a name is a built in rule. The one built in rule is clean,
+which removes unregistered torrents that have a registered twin of the same
+name. Every action taken is written to the file named by rules-log.
+
+For example, in ~/.trr.yaml:
+
+rules-log: ~/trr-rules.log
+rules:
+  - name: clean
+  - name: retire
+    match: tracker:example.org,seedtime>30d,ratio>2
+    actions:
+      - stop
+      - label retired
+      - move /archive
+    every: 6h
+
+every is only used by serve, which runs the rule that often.`,
+}
+
+var rulesRunCmd = &cobra.Command{
+	Use:   "run [rule]...",
+	Short: "Run rules",
+	Long:  `Run the named rules, or all of them, against the selected torrents.`,
+	Run:   doRulesRun,
+}
+
+var rulesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List rules",
+	Args:  cobra.NoArgs,
+	Run:   doRulesList,
+}
+
+// rule is a housekeeping rule from the config. Every, a duration, is how
+// often serve runs the rule; serve leaves rules without it alone.
+type rule struct {
+	Name    string   `mapstructure:"name"`
+	Match   string   `mapstructure:"match"`
+	Actions []string `mapstructure:"actions"`
+	Every   string   `mapstructure:"every"`
+
+	// pick, when set, chooses the torrents instead of Match.
+	pick func(ts []*rpcTorrent) []*rpcTorrent
+}
+
+var builtinRules = map[string]rule{
+	"clean": {Name: "clean", Actions: []string{"remove"}, pick: unregisteredTwins},
+}
+
+// loadRules returns the rules from the config, or those of them named in
+// names when there are any.
+func loadRules(names []string) ([]rule, error) {
+	var all []rule
+	if err := viper.UnmarshalKey("rules", &all); err != nil {
+		return nil, err
+	}
+	rs := []rule{}
+	for _, r := range all {
+		if len(names) > 0 && !containsString(names, r.Name) {
+			continue
+		}
+		if r.Match == "" && len(r.Actions) == 0 {
+			b, ok := builtinRules[r.Name]
+			if !ok {
+				return nil, fmt.Errorf("rule %s: no match or actions, and not built in", r.Name)
+			}
+			b.Every = r.Every
+			r = b
+		}
+		if r.Match == "" && r.pick == nil {
+			return nil, fmt.Errorf("rule %s: no match", r.Name)
+		}
+		rs = append(rs, r)
+	}
+	for _, n := range names {
+		found := false
+		for _, r := range rs {
+			found = found || r.Name == n
+		}
+		if !found {
+			return nil, fmt.Errorf("no rule %s", n)
+		}
+	}
+	return rs, nil
+}
+
+// ruleAction is a parsed rule action.
+type ruleAction struct {
+	text  string
+	apply func(x *rpcClient, t *rpcTorrent) error
+}
+
+// torrentMethod returns an action calling method on the torrent, with the
+// further arguments in args.
+func torrentMethod(method string, args map[string]interface{}) func(x *rpcClient, t *rpcTorrent) error {
+	return func(x *rpcClient, t *rpcTorrent) error {
+		a := map[string]interface{}{"ids": []int{t.ID}}
+		for k, v := range args {
+			a[k] = v
+		}
+		return x.call(method, a, nil)
+	}
+}
+
+func parseAction(s string) (ruleAction, error) {
+	fs := strings.Fields(s)
+	if len(fs) == 0 {
+		return ruleAction{}, errors.New("empty action")
+	}
+	verb, args := fs[0], fs[1:]
+	a := ruleAction{text: s}
+	wantArgs := func(ok bool) error {
+		if !ok {
+			return fmt.Errorf("%s: wrong number of arguments", s)
+		}
+		return nil
+	}
+	switch verb {
+	case "stop":
+		a.apply = torrentMethod("torrent-stop", nil)
+		return a, wantArgs(len(args) == 0)
+	case "reannounce":
+		a.apply = torrentMethod("torrent-reannounce", nil)
+		return a, wantArgs(len(args) == 0)
+	case "remove", "remove-with-data":
+		a.apply = torrentMethod("torrent-remove", map[string]interface{}{"delete-local-data": verb == "remove-with-data"})
+		return a, wantArgs(len(args) == 0)
+	case "move":
+		dir := strings.TrimSpace(strings.TrimPrefix(s, verb))
+		a.apply = torrentMethod("torrent-set-location", map[string]interface{}{"location": dir, "move": true})
+		return a, wantArgs(dir != "")
+	case "label":
+		a.apply = func(x *rpcClient, t *rpcTorrent) error {
+			ls := addLabels(t.Labels, args)
+			if err := x.torrentSet([]int{t.ID}, map[string]interface{}{"labels": ls}); err != nil {
+				return err
+			}
+			// later rules labelling t build on these labels
+			t.Labels = ls
+			return nil
+		}
+		return a, wantArgs(len(args) > 0)
+	case "set":
+		set := map[string]interface{}{}
+		for _, arg := range args {
+			kv := strings.SplitN(arg, "=", 2)
+			var ts *torrentSetting
+			for i := range torrentSettings {
+				if torrentSettings[i].flag == kv[0] {
+					ts = &torrentSettings[i]
+				}
+			}
+			if ts == nil || len(kv) != 2 {
+				return a, fmt.Errorf("%s: want <flag>=<value> using the flags of set", arg)
+			}
+			v, err := ts.parse(kv[1])
+			if err != nil {
+				return a, fmt.Errorf("%s: %s", arg, err)
+			}
+			set[ts.field] = v
+		}
+		a.apply = torrentMethod("torrent-set", set)
+		return a, wantArgs(len(args) > 0)
+	default:
+		return a, fmt.Errorf("%s: unknown action", verb)
+	}
+}
+
+// ruleLog opens the action log named by rules-log, or returns nil if there
+// is none.
+func ruleLog() (*log.Logger, *os.File, error) {
+	path := viper.GetString("rules-log")
+	if path == "" {
+		return nil, nil, nil
+	}
+	path, err := homedir.Expand(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+	return log.New(f, "", log.LstdFlags), f, nil
+}
+
+// runRules applies rs to the selected torrents. In a dry run the actions
+// are only shown.
+func runRules(x *rpcClient, rs []rule, dryRun bool) error {
+	filters := make([]torrentFilter, len(rs))
+	actions := make([][]ruleAction, len(rs))
+	for i, r := range rs {
+		if r.pick == nil {
+			f, err := parseFilter(r.Match)
+			if err != nil {
+				return fmt.Errorf("rule %s: %s", r.Name, err)
+			}
+			filters[i] = f
+		}
+		for _, s := range r.Actions {
+			a, err := parseAction(s)
+			if err != nil {
+				return fmt.Errorf("rule %s: %s", r.Name, err)
+			}
+			actions[i] = append(actions[i], a)
+		}
+	}
+	ids, err := getTorrents()
+	if err != nil {
+		return err
+	}
+	ts, err := x.torrentGet(ids, append(filterFields, "id", "hashString"))
+	if err != nil {
+		return err
+	}
+	var l *log.Logger
+	if !dryRun {
+		var f *os.File
+		if l, f, err = ruleLog(); err != nil {
+			return err
+		}
+		if f != nil {
+			defer f.Close()
+		}
+	}
+	removed := map[int]bool{}
+	for i, r := range rs {
+		var matched []*rpcTorrent
+		if r.pick != nil {
+			matched = r.pick(ts)
+		} else {
+			matched = filterTorrents(ts, filters[i])
+		}
+		for _, t := range matched {
+			for _, a := range actions[i] {
+				if removed[t.ID] {
+					break
+				}
+				line := fmt.Sprintf("rule %s: %s: torrent %d %s", r.Name, a.text, t.ID, t.Name)
+				if dryRun {
+					fmt.Println("dry run:", line)
+					continue
+				}
+				if err := a.apply(x, t); err != nil {
+					line += ": " + err.Error()
+				} else if strings.HasPrefix(a.text, "remove") {
+					removed[t.ID] = true
+				}
+				fmt.Println(line)
+				if l != nil {
+					l.Println(line)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func doRulesRun(cmd *cobra.Command, args []string) {
+	rs, err := loadRules(args)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if err = runRules(getRPC(), rs, rulesDryRun); err != nil {
+		fmt.Println(err)
+	}
+}
+
+func doRulesList(cmd *cobra.Command, args []string) {
+	rs, err := loadRules(nil)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, r := range rs {
+		match := r.Match
+		if r.pick != nil {
+			match = "(built in)"
+		}
+		fmt.Printf("%s: %s\n", r.Name, match)
+		for _, a := range r.Actions {
+			fmt.Printf("  %s\n", a)
+		}
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(rulesCmd)
+	rulesCmd.AddCommand(rulesRunCmd)
+	rulesCmd.AddCommand(rulesListCmd)
+
+	rulesRunCmd.Flags().BoolVarP(&rulesDryRun, "dry-run", "n", false, "only show what would be done")
+}
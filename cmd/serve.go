@@ -0,0 +1,258 @@
+// Copyright © 2017 Charles Haynes <ceh@ceh.bz>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run rules on a schedule and report status",
+	Long: `Keep running, polling the server every serve-poll and running each rule
+that has an every setting on that schedule (see trr rules). Status, including
+torrent counts, rates and when each rule last ran, is served as JSON at
+http://<serve-listen>/status.
+
+SIGHUP reloads the config file, picking up new rules, schedules and the poll
+interval; a new listen address needs a restart. SIGTERM or SIGINT shut down
+after the current poll.`,
+	Args: cobra.NoArgs,
+	Run:  doServe,
+}
+
+// ruleStatus is how the last run of a scheduled rule went.
+type ruleStatus struct {
+	LastRun time.Time `json:"lastRun"`
+	NextRun time.Time `json:"nextRun"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// serveStatus is what the status endpoint reports.
+type serveStatus struct {
+	mu            sync.Mutex
+	Started       time.Time             `json:"started"`
+	LastPoll      time.Time             `json:"lastPoll"`
+	PollError     string                `json:"pollError,omitempty"`
+	Torrents      int                   `json:"torrents"`
+	ByStatus      map[string]int        `json:"byStatus"`
+	UploadSpeed   int64                 `json:"uploadSpeed"`
+	DownloadSpeed int64                 `json:"downloadSpeed"`
+	Rules         map[string]ruleStatus `json:"rules"`
+}
+
+func (s *serveStatus) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s); err != nil {
+		log.Print(err)
+	}
+}
+
+// poll refreshes the torrent counts and rates.
+func (s *serveStatus) poll(x *rpcClient) {
+	ts, err := x.torrentGet(nil, []string{"id", "status", "error", "errorString", "rateDownload", "rateUpload"})
+	var st *rpcSessionStats
+	if err == nil {
+		st, err = x.sessionStats()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.LastPoll = time.Now()
+	if err != nil {
+		s.PollError = err.Error()
+		log.Print(err)
+		return
+	}
+	s.PollError = ""
+	s.Torrents = len(ts)
+	s.ByStatus = map[string]int{}
+	for _, t := range ts {
+		s.ByStatus[statusCategory(t)]++
+	}
+	s.UploadSpeed = st.UploadSpeed
+	s.DownloadSpeed = st.DownloadSpeed
+}
+
+// scheduledRule is a rule serve runs every so often.
+type scheduledRule struct {
+	rule
+	every time.Duration
+	next  time.Time
+}
+
+// scheduledRules returns the rules from the config that have an every
+// setting. Rules also in old, matched by name, keep their schedule, moved by
+// any change to every; new rules are due now.
+func scheduledRules(old []*scheduledRule) ([]*scheduledRule, error) {
+	rs, err := loadRules(nil)
+	if err != nil {
+		return nil, err
+	}
+	prev := map[string]*scheduledRule{}
+	for _, r := range old {
+		prev[r.Name] = r
+	}
+	srs := []*scheduledRule{}
+	for _, r := range rs {
+		if r.Every == "" {
+			continue
+		}
+		d, err := time.ParseDuration(r.Every)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: every: %s", r.Name, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("rule %s: every: %s is not positive", r.Name, r.Every)
+		}
+		next := time.Now()
+		if p, ok := prev[r.Name]; ok {
+			next = p.next.Add(d - p.every)
+		}
+		srs = append(srs, &scheduledRule{rule: r, every: d, next: next})
+	}
+	return srs, nil
+}
+
+// runDue runs the rules whose time has come.
+func (s *serveStatus) runDue(x *rpcClient, srs []*scheduledRule) {
+	for _, r := range srs {
+		now := time.Now()
+		if now.Before(r.next) {
+			continue
+		}
+		r.next = now.Add(r.every)
+		rs := ruleStatus{LastRun: now, NextRun: r.next}
+		if err := runRules(x, []rule{r.rule}, false); err != nil {
+			rs.Error = err.Error()
+			log.Printf("rule %s: %s", r.Name, err)
+		}
+		s.mu.Lock()
+		s.Rules[r.Name] = rs
+		s.mu.Unlock()
+	}
+}
+
+// servePoll returns the serve-poll setting, which has to be a positive
+// duration.
+func servePoll() (time.Duration, error) {
+	d := viper.GetDuration("serve-poll")
+	if d <= 0 {
+		return 0, fmt.Errorf("serve-poll: %q is not a positive duration", viper.GetString("serve-poll"))
+	}
+	return d, nil
+}
+
+func doServe(cmd *cobra.Command, args []string) {
+	poll, err := servePoll()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	srs, err := scheduledRules(nil)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	x := getRPC()
+	st := &serveStatus{Started: time.Now(), Rules: map[string]ruleStatus{}}
+	mux := http.NewServeMux()
+	mux.Handle("/status", st)
+	srv := &http.Server{Addr: viper.GetString("serve-listen"), Handler: mux}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+	log.Printf("serving status on http://%s/status", srv.Addr)
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
+	tick := time.NewTicker(poll)
+	defer func() { tick.Stop() }()
+	st.poll(x)
+	st.runDue(x, srs)
+	for {
+		select {
+		case <-tick.C:
+			st.poll(x)
+			st.runDue(x, srs)
+		case sig := <-sigs:
+			if sig != syscall.SIGHUP {
+				log.Printf("%s, shutting down", sig)
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				if err := srv.Shutdown(ctx); err != nil {
+					log.Print(err)
+				}
+				return
+			}
+			if err := viper.ReadInConfig(); err != nil {
+				log.Printf("reload: %s", err)
+				continue
+			}
+			rs, err := scheduledRules(srs)
+			if err != nil {
+				log.Printf("reload: %s", err)
+				continue
+			}
+			srs = rs
+			server = viper.GetString("server")
+			x = getRPC()
+			if p, err := servePoll(); err != nil {
+				log.Printf("reload: %s, still polling every %s", err, poll)
+			} else if p != poll {
+				poll = p
+				tick.Stop()
+				tick = time.NewTicker(poll)
+			}
+			log.Printf("reloaded %s", viper.ConfigFileUsed())
+		}
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().String("listen", "", "address for the status endpoint (default from serve-listen)")
+	serveCmd.Flags().Duration("poll", 0, "how often to poll the server (default from serve-poll)")
+	for key, flag := range map[string]string{"serve-listen": "listen", "serve-poll": "poll"} {
+		if err := viper.BindPFlag(key, serveCmd.Flags().Lookup(flag)); err != nil {
+			log.Fatal(err)
+		}
+	}
+	viper.SetDefault("serve-listen", "localhost:9192")
+	viper.SetDefault("serve-poll", time.Minute)
+}
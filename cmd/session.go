@@ -0,0 +1,285 @@
+// Copyright © 2017 Charles Haynes <ceh@ceh.bz>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var sessionDiff bool
+
+// sessionKind is the type of a session setting.
+type sessionKind int
+
+const (
+	sessionBool sessionKind = iota
+	sessionInt
+	sessionFloat
+	sessionString
+	sessionReadOnly
+)
+
+// sessionField is a session setting, with the values it accepts when values
+// is not empty.
+type sessionField struct {
+	key    string
+	kind   sessionKind
+	values []string
+}
+
+// sessionGroup is a set of related session settings shown together.
+type sessionGroup struct {
+	name   string
+	fields []sessionField
+}
+
+var sessionGroups = []sessionGroup{
+	{"Speed limits", []sessionField{
+		{key: "speed-limit-down", kind: sessionInt},
+		{key: "speed-limit-down-enabled", kind: sessionBool},
+		{key: "speed-limit-up", kind: sessionInt},
+		{key: "speed-limit-up-enabled", kind: sessionBool},
+	}},
+	{"Alternate speed", []sessionField{
+		{key: "alt-speed-enabled", kind: sessionBool},
+		{key: "alt-speed-down", kind: sessionInt},
+		{key: "alt-speed-up", kind: sessionInt},
+		{key: "alt-speed-time-enabled", kind: sessionBool},
+		{key: "alt-speed-time-begin", kind: sessionInt},
+		{key: "alt-speed-time-end", kind: sessionInt},
+		{key: "alt-speed-time-day", kind: sessionInt},
+	}},
+	{"Queueing", []sessionField{
+		{key: "download-queue-enabled", kind: sessionBool},
+		{key: "download-queue-size", kind: sessionInt},
+		{key: "seed-queue-enabled", kind: sessionBool},
+		{key: "seed-queue-size", kind: sessionInt},
+		{key: "queue-stalled-enabled", kind: sessionBool},
+		{key: "queue-stalled-minutes", kind: sessionInt},
+	}},
+	{"Seeding limits", []sessionField{
+		{key: "seedRatioLimit", kind: sessionFloat},
+		{key: "seedRatioLimited", kind: sessionBool},
+		{key: "idle-seeding-limit", kind: sessionInt},
+		{key: "idle-seeding-limit-enabled", kind: sessionBool},
+	}},
+	{"Peers and ports", []sessionField{
+		{key: "peer-limit-global", kind: sessionInt},
+		{key: "peer-limit-per-torrent", kind: sessionInt},
+		{key: "peer-port", kind: sessionInt},
+		{key: "peer-port-random-on-start", kind: sessionBool},
+		{key: "port-forwarding-enabled", kind: sessionBool},
+		{key: "dht-enabled", kind: sessionBool},
+		{key: "pex-enabled", kind: sessionBool},
+		{key: "lpd-enabled", kind: sessionBool},
+		{key: "utp-enabled", kind: sessionBool},
+	}},
+	{"Directories", []sessionField{
+		{key: "download-dir", kind: sessionString},
+		{key: "incomplete-dir", kind: sessionString},
+		{key: "incomplete-dir-enabled", kind: sessionBool},
+		{key: "rename-partial-files", kind: sessionBool},
+		{key: "start-added-torrents", kind: sessionBool},
+		{key: "trash-original-torrent-files", kind: sessionBool},
+		{key: "script-torrent-done-enabled", kind: sessionBool},
+		{key: "script-torrent-done-filename", kind: sessionString},
+		{key: "config-dir", kind: sessionReadOnly},
+	}},
+	{"Encryption", []sessionField{
+		{key: "encryption", kind: sessionString, values: []string{"required", "preferred", "tolerated"}},
+	}},
+	{"Blocklist", []sessionField{
+		{key: "blocklist-enabled", kind: sessionBool},
+		{key: "blocklist-url", kind: sessionString},
+		{key: "blocklist-size", kind: sessionReadOnly},
+	}},
+	{"Daemon", []sessionField{
+		{key: "version", kind: sessionReadOnly},
+		{key: "rpc-version", kind: sessionReadOnly},
+		{key: "rpc-version-minimum", kind: sessionReadOnly},
+		{key: "cache-size-mb", kind: sessionInt},
+		{key: "units", kind: sessionReadOnly},
+	}},
+}
+
+// sessionCmd represents the session command
+var sessionCmd = &cobra.Command{
+	Use:   "session",
+	Short: "Show the server's session settings",
+	Long: `Show the daemon wide settings, grouped by what they control. Settings trr
+doesn't know about are shown at the end.`,
+	Args: cobra.NoArgs,
+	Run:  doSession,
+}
+
+// sessionSetCmd represents the session set command
+var sessionSetCmd = &cobra.Command{
+	Use:   "set key=value...",
+	Short: "Change session settings",
+	Long: `Change daemon wide settings. Each value is checked against the type of its
+setting before anything is sent. With --diff the settings are read back and
+the changed values shown.
+
+Example:
+trr session set speed-limit-up=500 speed-limit-up-enabled=true --diff`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  doSessionSet,
+}
+
+// findSessionField looks up the known setting named key.
+func findSessionField(key string) (sessionField, bool) {
+	for _, g := range sessionGroups {
+		for _, f := range g.fields {
+			if f.key == key {
+				return f, true
+			}
+		}
+	}
+	return sessionField{}, false
+}
+
+// parse converts s to the type of f.
+func (f sessionField) parse(s string) (interface{}, error) {
+	switch f.kind {
+	case sessionBool:
+		return parseBool(s)
+	case sessionInt:
+		return strconv.Atoi(s)
+	case sessionFloat:
+		return strconv.ParseFloat(s, 64)
+	case sessionString:
+		if len(f.values) == 0 {
+			return s, nil
+		}
+		for _, v := range f.values {
+			if s == v {
+				return s, nil
+			}
+		}
+		return nil, fmt.Errorf("want one of %s", strings.Join(f.values, ", "))
+	default:
+		return nil, errors.New("setting is read only")
+	}
+}
+
+func doSession(cmd *cobra.Command, args []string) {
+	x := getRPC()
+	s, err := x.sessionGet()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	shown := map[string]bool{}
+	for _, g := range sessionGroups {
+		fmt.Printf("%s:\n", g.name)
+		for _, f := range g.fields {
+			shown[f.key] = true
+			if v, ok := s[f.key]; ok {
+				fmt.Printf("  %-30s %s\n", f.key, formatValue(v))
+			}
+		}
+	}
+	other := []string{}
+	for k := range s {
+		if !shown[k] {
+			other = append(other, k)
+		}
+	}
+	if len(other) == 0 {
+		return
+	}
+	sort.Strings(other)
+	fmt.Println("Other:")
+	for _, k := range other {
+		fmt.Printf("  %-30s %s\n", k, formatValue(s[k]))
+	}
+}
+
+func doSessionSet(cmd *cobra.Command, args []string) {
+	a := map[string]interface{}{}
+	keys := []string{}
+	for _, arg := range args {
+		kv := strings.SplitN(arg, "=", 2)
+		if len(kv) != 2 {
+			fmt.Printf("%s: want key=value\n", arg)
+			return
+		}
+		f, ok := findSessionField(kv[0])
+		if !ok {
+			fmt.Printf("%s: unknown setting\n", kv[0])
+			return
+		}
+		v, err := f.parse(kv[1])
+		if err != nil {
+			fmt.Printf("%s: %s\n", kv[0], err)
+			return
+		}
+		a[f.key] = v
+		keys = append(keys, f.key)
+	}
+	x := getRPC()
+	var before map[string]interface{}
+	var err error
+	if sessionDiff {
+		if before, err = x.sessionGet(keys...); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+	if err = x.sessionSet(a); err != nil {
+		fmt.Println(err)
+		return
+	}
+	if !sessionDiff {
+		return
+	}
+	after, err := x.sessionGet(keys...)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	printSessionDiff(before, after, keys)
+}
+
+// printSessionDiff shows how each of keys changed from before to after.
+func printSessionDiff(before, after map[string]interface{}, keys []string) {
+	for _, k := range keys {
+		o, n := formatValue(before[k]), formatValue(after[k])
+		if o == n {
+			fmt.Printf("%s: %s (unchanged)\n", k, n)
+			continue
+		}
+		fmt.Printf("%s: %s -> %s\n", k, o, n)
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(sessionCmd)
+	sessionCmd.AddCommand(sessionSetCmd)
+
+	sessionSetCmd.Flags().BoolVar(&sessionDiff, "diff", false, "show what changed")
+}
@@ -0,0 +1,276 @@
+// Copyright © 2017 Charles Haynes <ceh@ceh.bz>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	units "github.com/docker/go-units"
+	"github.com/spf13/cobra"
+)
+
+// torrentSetting describes a torrent-set field and the flag that sets it.
+// parse validates the flag value and converts it to what the RPC expects.
+type torrentSetting struct {
+	flag  string
+	field string
+	usage string
+	parse func(string) (interface{}, error)
+}
+
+var torrentSettings = []torrentSetting{
+	{"down-limit", "downloadLimit", "download limit in kB/s, or with a unit, e.g. 500K", parseRate},
+	{"down-limited", "downloadLimited", "honor the download limit (true/false)", parseBool},
+	{"up-limit", "uploadLimit", "upload limit in kB/s, or with a unit, e.g. 2M", parseRate},
+	{"up-limited", "uploadLimited", "honor the upload limit (true/false)", parseBool},
+	{"ratio", "seedRatioLimit", "seed ratio limit", parseRatio},
+	{"ratio-mode", "seedRatioMode", "seed ratio mode (global, single, unlimited)", parseSeedMode},
+	{"idle-limit", "seedIdleLimit", "seed idle limit, in minutes or as a duration like 2h", parseMinutes},
+	{"idle-mode", "seedIdleMode", "seed idle mode (global, single, unlimited)", parseSeedMode},
+	{"peer-limit", "peer-limit", "maximum number of peers", parsePeerLimit},
+	{"priority", "bandwidthPriority", "bandwidth priority (low, normal, high)", parsePriority},
+	{"queue-position", "queuePosition", "position in the queue, 0 is first", parseQueuePosition},
+	{"honor-session", "honorsSessionLimits", "honor the session speed limits (true/false)", parseBool},
+	{"labels", "labels", "comma separated list of labels, replaces existing labels", parseLabels},
+}
+
+var setValues = map[string]*string{}
+
+// setCmd represents the set command
+var setCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Change settings of torrents",
+	Long: `Change per torrent settings for the selected torrents.
+
+Only the settings given as flags are changed. After applying them the torrents
+are read back and each changed value is shown as old -> new.
+
+Example:
+trr set -t 3,7 --up-limit 500K --up-limited true --ratio 2 --ratio-mode single`,
+	Args: cobra.NoArgs,
+	Run:  doSet,
+}
+
+func doSet(cmd *cobra.Command, args []string) {
+	a := map[string]interface{}{}
+	fields := []string{"id", "name"}
+	for _, s := range torrentSettings {
+		if !cmd.Flags().Changed(s.flag) {
+			continue
+		}
+		v, err := s.parse(*setValues[s.flag])
+		if err != nil {
+			fmt.Printf("--%s: %s\n", s.flag, err)
+			return
+		}
+		a[s.field] = v
+		fields = append(fields, s.field)
+	}
+	if len(a) == 0 {
+		fmt.Println("nothing to set")
+		return
+	}
+	ids, err := getTorrents()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	x := getRPC()
+	before, err := x.torrentGetRaw(ids, fields)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if err = x.torrentSet(ids, a); err != nil {
+		fmt.Println(err)
+		return
+	}
+	after, err := x.torrentGetRaw(ids, fields)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	printTorrentDiff(before, after, fields[2:])
+}
+
+// printTorrentDiff shows, for each torrent in after, how fields differ from
+// the same torrent in before.
+func printTorrentDiff(before, after []map[string]interface{}, fields []string) {
+	old := map[string]map[string]interface{}{}
+	for _, t := range before {
+		old[formatValue(t["id"])] = t
+	}
+	for _, t := range after {
+		fmt.Printf("Torrent %s: %s\n", formatValue(t["id"]), formatValue(t["name"]))
+		o := old[formatValue(t["id"])]
+		for _, f := range fields {
+			ov, nv := formatValue(o[f]), formatValue(t[f])
+			if ov == nv {
+				fmt.Printf("  %s: %s (unchanged)\n", f, nv)
+				continue
+			}
+			fmt.Printf("  %s: %s -> %s\n", f, ov, nv)
+		}
+	}
+}
+
+// parseRate parses a rate into the kB/s used by the RPC speed limits. A bare
+// number is already kB/s, like in the RPC; with a unit, like 500K or 2M, it is
+// bytes per second. Binary units like 512KiB are 1024 based.
+func parseRate(s string) (interface{}, error) {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if n < 0 {
+			return nil, errors.New("rate must not be negative")
+		}
+		return n, nil
+	}
+	fromHumanSize := units.FromHumanSize
+	if u := strings.ToLower(s); strings.HasSuffix(u, "i") || strings.HasSuffix(u, "ib") {
+		fromHumanSize = units.RAMInBytes
+	}
+	n, err := fromHumanSize(s)
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 {
+		return nil, errors.New("rate must not be negative")
+	}
+	if n > 0 && n < 1000 {
+		return nil, fmt.Errorf("%s is less than 1 kB/s", s)
+	}
+	return n / 1000, nil
+}
+
+func parseBool(s string) (interface{}, error) {
+	switch strings.ToLower(s) {
+	case "on", "yes":
+		return true, nil
+	case "off", "no":
+		return false, nil
+	}
+	return strconv.ParseBool(s)
+}
+
+func parseRatio(s string) (interface{}, error) {
+	r, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, err
+	}
+	if r < 0 {
+		return nil, errors.New("ratio must not be negative")
+	}
+	return r, nil
+}
+
+// seed ratio and idle modes
+const (
+	seedModeGlobal = iota
+	seedModeSingle
+	seedModeUnlimited
+)
+
+func parseSeedMode(s string) (interface{}, error) {
+	switch strings.ToLower(s) {
+	case "global":
+		return seedModeGlobal, nil
+	case "single":
+		return seedModeSingle, nil
+	case "unlimited":
+		return seedModeUnlimited, nil
+	default:
+		return nil, fmt.Errorf("unknown mode %q, want global, single or unlimited", s)
+	}
+}
+
+func parseMinutes(s string) (interface{}, error) {
+	m, err := strconv.Atoi(s)
+	if err != nil {
+		d, derr := time.ParseDuration(s)
+		if derr != nil {
+			return nil, fmt.Errorf("%q is neither minutes nor a duration", s)
+		}
+		m = int(d / time.Minute)
+	}
+	if m < 0 {
+		return nil, errors.New("idle limit must not be negative")
+	}
+	return m, nil
+}
+
+func parsePeerLimit(s string) (interface{}, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return nil, err
+	}
+	if n < 1 {
+		return nil, errors.New("peer limit must be at least 1")
+	}
+	return n, nil
+}
+
+func parsePriority(s string) (interface{}, error) {
+	switch strings.ToLower(s) {
+	case "low":
+		return -1, nil
+	case "normal":
+		return 0, nil
+	case "high":
+		return 1, nil
+	default:
+		return nil, fmt.Errorf("unknown priority %q, want low, normal or high", s)
+	}
+}
+
+func parseQueuePosition(s string) (interface{}, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 {
+		return nil, errors.New("queue position must not be negative")
+	}
+	return n, nil
+}
+
+func parseLabels(s string) (interface{}, error) {
+	ls := []string{}
+	for _, l := range strings.Split(s, ",") {
+		l = strings.TrimSpace(l)
+		if l != "" {
+			ls = append(ls, l)
+		}
+	}
+	return ls, nil
+}
+
+func init() {
+	RootCmd.AddCommand(setCmd)
+
+	for _, s := range torrentSettings {
+		v := new(string)
+		setValues[s.flag] = v
+		setCmd.Flags().StringVar(v, s.flag, "", s.usage)
+	}
+}
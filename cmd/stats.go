@@ -0,0 +1,84 @@
+// Copyright © 2017 Charles Haynes <ceh@ceh.bz>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	units "github.com/docker/go-units"
+	"github.com/spf13/cobra"
+)
+
+// statsCmd represents the stats command
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Session and cumulative statistics",
+	Long: `Show the current transfer rates and torrent counts, and the bytes
+transferred, files added, time active and number of sessions both for the
+current session and for all time.`,
+	Args: cobra.NoArgs,
+	Run:  doStats,
+}
+
+func doStats(cmd *cobra.Command, args []string) {
+	x := getRPC()
+	s, err := x.sessionStats()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("Rates:     %s up, %s down\n",
+		units.HumanSize(float64(s.UploadSpeed)),
+		units.HumanSize(float64(s.DownloadSpeed)))
+	fmt.Printf("Torrents:  %d active, %d paused, %d total\n",
+		s.ActiveTorrentCount,
+		s.PausedTorrentCount,
+		s.TorrentCount)
+	fmt.Println()
+	fmt.Println("                Session   Cumulative")
+	cur, cum := s.Current, s.Cumulative
+	printStat("Uploaded", units.HumanSize(float64(cur.UploadedBytes)), units.HumanSize(float64(cum.UploadedBytes)))
+	printStat("Downloaded", units.HumanSize(float64(cur.DownloadedBytes)), units.HumanSize(float64(cum.DownloadedBytes)))
+	printStat("Ratio", statsRatio(cur), statsRatio(cum))
+	printStat("Files added", strconv.FormatInt(cur.FilesAdded, 10), strconv.FormatInt(cum.FilesAdded, 10))
+	printStat("Active", myDuration(cur.SecondsActive), myDuration(cum.SecondsActive))
+	printStat("Sessions", strconv.FormatInt(cur.SessionCount, 10), strconv.FormatInt(cum.SessionCount, 10))
+}
+
+func printStat(name, session, cumulative string) {
+	fmt.Printf("%-12s %10s %12s\n", name, session, cumulative)
+}
+
+// statsRatio is uploaded over downloaded, or ∞ when nothing was downloaded.
+func statsRatio(s rpcStats) string {
+	if s.DownloadedBytes == 0 {
+		if s.UploadedBytes == 0 {
+			return "0.0"
+		}
+		return "∞"
+	}
+	return fmt.Sprintf("%.2f", float64(s.UploadedBytes)/float64(s.DownloadedBytes))
+}
+
+func init() {
+	RootCmd.AddCommand(statsCmd)
+}
@@ -0,0 +1,98 @@
+// Copyright © 2017 Charles Haynes <ceh@ceh.bz>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	units "github.com/docker/go-units"
+	"github.com/spf13/cobra"
+)
+
+// torrentCmd represents the torrent command
+var torrentCmd = &cobra.Command{
+	Use:   "torrent",
+	Short: "Work with local .torrent files",
+	Long:  `Commands for .torrent files on this machine. They don't contact the server.`,
+}
+
+var torrentShowCmd = &cobra.Command{
+	Use:   "show <file.torrent>...",
+	Short: "Show what is in a .torrent file",
+	Args:  cobra.MinimumNArgs(1),
+	Run:   doTorrentShow,
+}
+
+func doTorrentShow(cmd *cobra.Command, args []string) {
+	for i, path := range args {
+		if i > 0 {
+			fmt.Println()
+		}
+		m, err := loadMetainfo(path)
+		if err != nil {
+			fmt.Printf("%s: %s\n", path, err)
+			continue
+		}
+		fmt.Printf("Torrent file: %s\n", path)
+		printMetainfo(m)
+	}
+}
+
+func printMetainfo(m *metainfo) {
+	infoField("Name", m.name)
+	if m.infoHashV1 != "" {
+		infoField("Hash v1", m.infoHashV1)
+	}
+	if m.infoHashV2 != "" {
+		infoField("Hash v2", m.infoHashV2)
+	}
+	infoField("Size", units.HumanSize(float64(m.totalSize())))
+	infoField("Pieces", fmt.Sprintf("%d x %s", m.pieceCount(), units.HumanSize(float64(m.pieceLength))))
+	infoField("Private", strconv.FormatBool(m.private))
+	if m.source != "" {
+		infoField("Source", m.source)
+	}
+	infoField("Created", fmt.Sprintf("%s by %s", infoDate(m.creationDate), m.creator))
+	if m.comment != "" {
+		infoField("Comment", m.comment)
+	}
+	fmt.Println("  Trackers")
+	for tier, us := range m.trackers {
+		for _, u := range us {
+			fmt.Printf("    %4d %s\n", tier, u)
+		}
+	}
+	for _, u := range m.webSeeds {
+		fmt.Printf("    seed %s\n", u)
+	}
+	fmt.Println("  Files")
+	for _, f := range m.files {
+		if !f.pad {
+			fmt.Printf("    %7s  %s\n", units.HumanSize(float64(f.length)), f.path)
+		}
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(torrentCmd)
+	torrentCmd.AddCommand(torrentShowCmd)
+}
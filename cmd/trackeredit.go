@@ -0,0 +1,286 @@
+// Copyright © 2017 Charles Haynes <ceh@ceh.bz>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// trackerListVersion is the first RPC version that replaces trackerAdd,
+// trackerRemove and trackerReplace with trackerList.
+const trackerListVersion = 17
+
+var trackerDryRun, trackerRegex bool
+
+// trackerEditCmd represents the trackers command
+var trackerEditCmd = &cobra.Command{
+	Use:   "trackers",
+	Short: "Edit and report on the trackers of torrents",
+	Long: `Add, remove or replace announce URLs of the selected torrents, or report
+on tracker health across them.
+
+Each change is printed before it is applied. Use --dry-run to only print them.
+
+Example:
+trr trackers replace -t all passkey=OLD passkey=NEW`,
+}
+
+var trackerAddCmd = &cobra.Command{
+	Use:   "add <announce-url>...",
+	Short: "Add announce URLs to torrents",
+	Long: `Add each announce URL, in a tier of its own, to the selected torrents.
+URLs a torrent already has are skipped.`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  doTrackerAdd,
+}
+
+var trackerRemoveCmd = &cobra.Command{
+	Use:   "remove <pattern>...",
+	Short: "Remove announce URLs from torrents",
+	Long: `Remove every tracker whose announce URL contains one of the patterns.
+With --regex the patterns are regular expressions.`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  doTrackerRemove,
+}
+
+var trackerReplaceCmd = &cobra.Command{
+	Use:   "replace <old> <new>",
+	Short: "Replace part of announce URLs",
+	Long: `Replace every occurrence of old with new in the announce URLs of the
+selected torrents. With --regex old is a regular expression and new may refer
+to submatches as $1, $2 and so on.`,
+	Args: cobra.ExactArgs(2),
+	Run:  doTrackerReplace,
+}
+
+// trackerReplacement swaps the announce URL of an existing tracker.
+type trackerReplacement struct {
+	tracker rpcTracker
+	url     string
+}
+
+// trackerEdit is the set of changes to one torrent's trackers.
+type trackerEdit struct {
+	add     []string
+	remove  []rpcTracker
+	replace []trackerReplacement
+}
+
+func (e trackerEdit) empty() bool {
+	return len(e.add) == 0 && len(e.remove) == 0 && len(e.replace) == 0
+}
+
+func (e trackerEdit) print() {
+	for _, r := range e.replace {
+		fmt.Printf("  ~ %s -> %s\n", r.tracker.Announce, r.url)
+	}
+	for _, r := range e.remove {
+		fmt.Printf("  - %s\n", r.Announce)
+	}
+	for _, u := range e.add {
+		fmt.Printf("  + %s\n", u)
+	}
+}
+
+// setArgs returns the torrent-set arguments that apply e to t. Daemons
+// speaking trackerListVersion or later get the whole new tracker list.
+func (e trackerEdit) setArgs(t *rpcTorrent, version int) map[string]interface{} {
+	if version < trackerListVersion {
+		a := map[string]interface{}{}
+		if len(e.add) > 0 {
+			a["trackerAdd"] = e.add
+		}
+		if len(e.remove) > 0 {
+			ids := make([]int, len(e.remove))
+			for i, r := range e.remove {
+				ids[i] = r.ID
+			}
+			a["trackerRemove"] = ids
+		}
+		if len(e.replace) > 0 {
+			pairs := []interface{}{}
+			for _, r := range e.replace {
+				pairs = append(pairs, r.tracker.ID, r.url)
+			}
+			a["trackerReplace"] = pairs
+		}
+		return a
+	}
+	removed := map[int]bool{}
+	for _, r := range e.remove {
+		removed[r.ID] = true
+	}
+	replaced := map[int]string{}
+	for _, r := range e.replace {
+		replaced[r.tracker.ID] = r.url
+	}
+	tiers := map[int][]string{}
+	for _, tr := range t.Trackers {
+		if removed[tr.ID] {
+			continue
+		}
+		u := tr.Announce
+		if r, ok := replaced[tr.ID]; ok {
+			u = r
+		}
+		tiers[tr.Tier] = append(tiers[tr.Tier], u)
+	}
+	order := []int{}
+	for tier := range tiers {
+		order = append(order, tier)
+	}
+	sort.Ints(order)
+	list := []string{}
+	for _, tier := range order {
+		list = append(list, strings.Join(tiers[tier], "\n"))
+	}
+	list = append(list, e.add...)
+	return map[string]interface{}{"trackerList": strings.Join(list, "\n\n")}
+}
+
+// editTrackers computes an edit for each selected torrent, prints it and,
+// unless this is a dry run, applies it.
+func editTrackers(edit func(t *rpcTorrent) trackerEdit) {
+	x := getRPC()
+	version, err := x.rpcVersion()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	ids, err := getTorrents()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	ts, err := x.torrentGet(ids, []string{"id", "name", "trackers"})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, t := range ts {
+		e := edit(t)
+		if e.empty() {
+			continue
+		}
+		fmt.Printf("Torrent %d: %s\n", t.ID, t.Name)
+		e.print()
+		if trackerDryRun {
+			continue
+		}
+		if err := x.torrentSet([]int{t.ID}, e.setArgs(t, version)); err != nil {
+			fmt.Println(err)
+		}
+	}
+}
+
+// trackerMatcher returns a func reporting whether an announce URL matches
+// pattern, either as a substring or, with --regex, as a regular expression.
+func trackerMatcher(pattern string) (func(string) bool, error) {
+	if !trackerRegex {
+		return func(u string) bool { return strings.Contains(u, pattern) }, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return re.MatchString, nil
+}
+
+func doTrackerAdd(cmd *cobra.Command, args []string) {
+	editTrackers(func(t *rpcTorrent) trackerEdit {
+		have := map[string]bool{}
+		for _, tr := range t.Trackers {
+			have[tr.Announce] = true
+		}
+		var e trackerEdit
+		for _, u := range args {
+			if !have[u] {
+				have[u] = true
+				e.add = append(e.add, u)
+			}
+		}
+		return e
+	})
+}
+
+func doTrackerRemove(cmd *cobra.Command, args []string) {
+	ms := []func(string) bool{}
+	for _, p := range args {
+		m, err := trackerMatcher(p)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		ms = append(ms, m)
+	}
+	editTrackers(func(t *rpcTorrent) trackerEdit {
+		var e trackerEdit
+		for _, tr := range t.Trackers {
+			for _, m := range ms {
+				if m(tr.Announce) {
+					e.remove = append(e.remove, tr)
+					break
+				}
+			}
+		}
+		return e
+	})
+}
+
+func doTrackerReplace(cmd *cobra.Command, args []string) {
+	replace := func(u string) string { return strings.Replace(u, args[0], args[1], -1) }
+	if trackerRegex {
+		re, err := regexp.Compile(args[0])
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		replace = func(u string) string { return re.ReplaceAllString(u, args[1]) }
+	}
+	editTrackers(func(t *rpcTorrent) trackerEdit {
+		var e trackerEdit
+		for _, tr := range t.Trackers {
+			if u := replace(tr.Announce); u != tr.Announce {
+				e.replace = append(e.replace, trackerReplacement{tr, u})
+			}
+		}
+		return e
+	})
+}
+
+func init() {
+	RootCmd.AddCommand(trackerEditCmd)
+	trackerEditCmd.AddCommand(trackerAddCmd)
+	trackerEditCmd.AddCommand(trackerRemoveCmd)
+	trackerEditCmd.AddCommand(trackerReplaceCmd)
+
+	for _, c := range []*cobra.Command{trackerAddCmd, trackerRemoveCmd, trackerReplaceCmd} {
+		c.Flags().BoolVar(&trackerDryRun, "dry-run", false, "only show the changes")
+	}
+	for _, c := range []*cobra.Command{trackerRemoveCmd, trackerReplaceCmd} {
+		c.Flags().BoolVar(&trackerRegex, "regex", false, "patterns are regular expressions")
+	}
+}
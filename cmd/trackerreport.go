@@ -0,0 +1,161 @@
+// Copyright © 2017 Charles Haynes <ceh@ceh.bz>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+var reportErrors int
+
+// trackerReportCmd represents the trackers report command
+var trackerReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Tracker health across torrents",
+	Long: `Group the tracker stats of the selected torrents by tracker host.
+
+For each host show how many torrents use it, how many of their last announces
+succeeded and failed, the total seeders and leechers, how long ago the oldest
+successful announce was, and the most common announce errors.`,
+	Args: cobra.NoArgs,
+	Run:  doTrackerReport,
+}
+
+// trackerHealth accumulates the tracker stats for one host.
+type trackerHealth struct {
+	host     string
+	torrents map[int]bool
+	ok       int
+	failed   int
+	seeders  int
+	leechers int
+	oldestOK int64
+	errors   map[string]int
+}
+
+func (h *trackerHealth) add(id int, s rpcTrackerStat) {
+	h.torrents[id] = true
+	if s.SeederCount > 0 {
+		h.seeders += s.SeederCount
+	}
+	if s.LeecherCount > 0 {
+		h.leechers += s.LeecherCount
+	}
+	if !s.HasAnnounced {
+		return
+	}
+	if !s.LastAnnounceSucceeded {
+		h.failed++
+		h.errors[s.LastAnnounceResult]++
+		return
+	}
+	h.ok++
+	if h.oldestOK == 0 || s.LastAnnounceTime < h.oldestOK {
+		h.oldestOK = s.LastAnnounceTime
+	}
+}
+
+// commonErrors returns up to n error strings, most frequent first.
+func (h *trackerHealth) commonErrors(n int) []string {
+	es := []string{}
+	for e := range h.errors {
+		es = append(es, e)
+	}
+	sort.Slice(es, func(i, j int) bool {
+		if h.errors[es[i]] != h.errors[es[j]] {
+			return h.errors[es[i]] > h.errors[es[j]]
+		}
+		return es[i] < es[j]
+	})
+	if n < 0 {
+		n = 0
+	}
+	if len(es) > n {
+		es = es[:n]
+	}
+	return es
+}
+
+func doTrackerReport(cmd *cobra.Command, args []string) {
+	if reportErrors < 0 {
+		fmt.Println("--errors must not be negative")
+		return
+	}
+	x := getRPC()
+	ids, err := getTorrents()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	ts, err := x.torrentGet(ids, []string{"id", "trackerStats"})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("Torrents     OK   Fail  Seeders Leechers  Oldest OK Host")
+	for _, h := range trackerHosts(ts) {
+		fmt.Printf("%8d %6d %6d %8d %8d %10s %s\n",
+			len(h.torrents),
+			h.ok,
+			h.failed,
+			h.seeders,
+			h.leechers,
+			myDurationSince(h.oldestOK),
+			h.host)
+		for _, e := range h.commonErrors(reportErrors) {
+			fmt.Printf("%15d  %s\n", h.errors[e], e)
+		}
+	}
+}
+
+// trackerHosts groups the tracker stats of ts by host, sorted by host.
+func trackerHosts(ts []*rpcTorrent) []*trackerHealth {
+	hosts := map[string]*trackerHealth{}
+	for _, t := range ts {
+		for _, s := range t.TrackerStats {
+			h, ok := hosts[s.Host]
+			if !ok {
+				h = &trackerHealth{
+					host:     s.Host,
+					torrents: map[int]bool{},
+					errors:   map[string]int{},
+				}
+				hosts[s.Host] = h
+			}
+			h.add(t.ID, s)
+		}
+	}
+	hs := []*trackerHealth{}
+	for _, h := range hosts {
+		hs = append(hs, h)
+	}
+	sort.Slice(hs, func(i, j int) bool { return hs[i].host < hs[j].host })
+	return hs
+}
+
+func init() {
+	trackerEditCmd.AddCommand(trackerReportCmd)
+
+	trackerReportCmd.Flags().IntVar(&reportErrors, "errors", 3, "how many of the most common errors to show per host")
+}
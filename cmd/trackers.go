@@ -23,48 +23,147 @@ package cmd
 import (
 	"fmt"
 	"math"
+	"net/url"
+	"regexp"
+	"strings"
 	"time"
 
-	"github.com/charles-haynes/transmission"
 	"github.com/spf13/cobra"
 )
 
+var trackersFailing, trackersSecrets bool
+
 // trackersCmd represents the trackers command
 var trackersCmd = &cobra.Command{
 	Use:   "trackers",
 	Short: "Info about the trackers of a torrent",
 	Long: `For each torrent, display details about each tracker for that torrent.
-Includes information about tier, peers, seeders, leechers, times for announce and scrape
-as well as the host name of the tracker.`,
+Includes information about tier, peers, seeders, leechers, downloads, times and
+states for announce and scrape as well as the host name of the tracker. Below
+each tracker are its announce URL, with secrets such as passkeys redacted
+unless --secrets is given, and the results of its last announce and scrape.`,
 	Run: doInfoTrackers,
 }
 
 func doInfoTrackers(cmd *cobra.Command, args []string) {
-	x := getServer()
-	c := transmission.NewGetTorrentsCmd()
-	c.Arguments.Ids = getTorrents()
-	c.Arguments.Fields = []string{"trackerStats", "id", "name"}
-	res, err := x.ExecuteCommand(c)
+	x := getRPC()
+	ids, err := getTorrents()
 	if err != nil {
 		fmt.Println(err)
 		return
 	}
-	for _, t := range res.Arguments.Torrents {
+	ts, err := x.torrentGet(ids, []string{"trackerStats", "id", "name"})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, t := range ts {
+		ss := t.TrackerStats
+		if trackersFailing {
+			ss = failingTrackers(ss)
+			if len(ss) == 0 {
+				continue
+			}
+		}
 		fmt.Printf("Torrent %d: %s\n", t.ID, t.Name)
-		fmt.Println("Tier Peers Se Le    Last Sc    Next Sc   Last Ann   Next Ann Name")
-		for _, s := range t.TrackerStats {
-			fmt.Printf("%4d %5d %2d %2d %10s %10s %10s %10s %s\n",
+		fmt.Println("Tier Peers Se Le    Dl    Last Sc    Next Sc   Last Ann   Next Ann Announce Scrape   Name")
+		for _, s := range ss {
+			host := s.Host
+			if s.IsBackup {
+				host += " (backup)"
+			}
+			fmt.Printf("%4d %5d %2d %2d %5d %10s %10s %10s %10s %-8s %-8s %s\n",
 				s.Tier,
 				s.LastAnnouncePeerCount,
 				s.SeederCount,
 				s.LeecherCount,
+				s.DownloadCount,
 				myDurationSince(s.LastScrapeTime),
 				myDurationTill(s.NextScrapeTime),
 				myDurationSince(s.LastAnnounceTime),
 				myDurationTill(s.NextAnnounceTime),
-				s.Host)
+				trackerState(s.AnnounceState),
+				trackerState(s.ScrapeState),
+				host)
+			announce := s.Announce
+			if !trackersSecrets {
+				announce = redactURL(announce)
+			}
+			fmt.Printf("     announce: %s\n", announce)
+			if s.HasAnnounced {
+				fmt.Printf("     last announce: %s %s\n", succeeded(s.LastAnnounceSucceeded), s.LastAnnounceResult)
+			}
+			if s.HasScraped {
+				fmt.Printf("     last scrape: %s %s\n", succeeded(s.LastScrapeSucceeded), s.LastScrapeResult)
+			}
+		}
+	}
+}
+
+// failingTrackers returns the trackers whose last announce failed.
+func failingTrackers(ss []rpcTrackerStat) []rpcTrackerStat {
+	f := []rpcTrackerStat{}
+	for _, s := range ss {
+		if s.HasAnnounced && !s.LastAnnounceSucceeded {
+			f = append(f, s)
+		}
+	}
+	return f
+}
+
+func succeeded(ok bool) string {
+	if ok {
+		return "ok"
+	}
+	return "FAILED"
+}
+
+// trackerState names the announceState and scrapeState values.
+func trackerState(s int) string {
+	switch s {
+	case 0:
+		return "inactive"
+	case 1:
+		return "waiting"
+	case 2:
+		return "queued"
+	case 3:
+		return "active"
+	default:
+		return "unknown"
+	}
+}
+
+// secretSegment matches path segments that look like passkeys.
+var secretSegment = regexp.MustCompile(`^[A-Za-z0-9_-]{16,}$`)
+
+// redactURL hides the parts of an announce URL that usually carry secrets:
+// user info, query values and long opaque path segments.
+func redactURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return "[redacted]"
+	}
+	if u.User != nil {
+		u.User = url.User("redacted")
+	}
+	q := u.Query()
+	for k, vs := range q {
+		for i := range vs {
+			vs[i] = "redacted"
 		}
+		q[k] = vs
 	}
+	u.RawQuery = q.Encode()
+	segs := strings.Split(u.Path, "/")
+	for i, seg := range segs {
+		if secretSegment.MatchString(seg) {
+			segs[i] = "redacted"
+		}
+	}
+	u.Path = strings.Join(segs, "/")
+	u.RawPath = ""
+	return u.String()
 }
 
 const (
@@ -119,4 +218,7 @@ func myDuration(d int64) string {
 
 func init() {
 	infoCmd.AddCommand(trackersCmd)
+
+	trackersCmd.Flags().BoolVar(&trackersFailing, "failing", false, "only show trackers whose last announce failed")
+	trackersCmd.Flags().BoolVar(&trackersSecrets, "secrets", false, "show announce URLs without redacting secrets")
 }
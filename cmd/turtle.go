@@ -0,0 +1,334 @@
+// Copyright © 2017 Charles Haynes <ceh@ceh.bz>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	units "github.com/docker/go-units"
+	"github.com/spf13/cobra"
+)
+
+var turtleUp, turtleDown string
+
+// turtleCmd represents the turtle command
+var turtleCmd = &cobra.Command{
+	Use:   "turtle",
+	Short: "Alternate speed limits (turtle mode)",
+	Long: `Turn the alternate speed limits on or off, and show or change when they are
+turned on automatically.`,
+}
+
+var turtleOnCmd = &cobra.Command{
+	Use:   "on",
+	Short: "Turn turtle mode on",
+	Args:  cobra.NoArgs,
+	Run:   func(cmd *cobra.Command, args []string) { setTurtle(func(bool) bool { return true }) },
+}
+
+var turtleOffCmd = &cobra.Command{
+	Use:   "off",
+	Short: "Turn turtle mode off",
+	Args:  cobra.NoArgs,
+	Run:   func(cmd *cobra.Command, args []string) { setTurtle(func(bool) bool { return false }) },
+}
+
+var turtleToggleCmd = &cobra.Command{
+	Use:   "toggle",
+	Short: "Toggle turtle mode",
+	Args:  cobra.NoArgs,
+	Run:   func(cmd *cobra.Command, args []string) { setTurtle(func(on bool) bool { return !on }) },
+}
+
+var turtleStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show turtle mode, limits and schedule",
+	Args:  cobra.NoArgs,
+	Run:   doTurtleStatus,
+}
+
+var turtleScheduleCmd = &cobra.Command{
+	Use:   "schedule [days hh:mm-hh:mm | on | off]",
+	Short: "Show or change the turtle mode schedule",
+	Long: `Without arguments show the schedule. Otherwise set the days and times turtle
+mode is on, or turn the schedule on or off. Days are "every day", "weekdays",
+"weekends", or a list of days and ranges of days such as "mon-wed,fri".
+--up and --down change the alternate speed limits.
+
+Example:
+trr turtle schedule weekdays 09:00-18:00 --up 100K --down 1M`,
+	Run: doTurtleSchedule,
+}
+
+var turtleFields = []string{
+	"alt-speed-enabled",
+	"alt-speed-up",
+	"alt-speed-down",
+	"alt-speed-time-enabled",
+	"alt-speed-time-begin",
+	"alt-speed-time-end",
+	"alt-speed-time-day",
+}
+
+// turtleSession is the alternate speed part of the session settings.
+type turtleSession struct {
+	enabled     bool
+	up, down    int64
+	timeEnabled bool
+	begin, end  int
+	days        int
+}
+
+func getTurtle(x *rpcClient) (*turtleSession, error) {
+	s, err := x.sessionGet(turtleFields...)
+	if err != nil {
+		return nil, err
+	}
+	num := func(k string) float64 { f, _ := s[k].(float64); return f }
+	flag := func(k string) bool { b, _ := s[k].(bool); return b }
+	return &turtleSession{
+		enabled:     flag("alt-speed-enabled"),
+		up:          int64(num("alt-speed-up")),
+		down:        int64(num("alt-speed-down")),
+		timeEnabled: flag("alt-speed-time-enabled"),
+		begin:       int(num("alt-speed-time-begin")),
+		end:         int(num("alt-speed-time-end")),
+		days:        int(num("alt-speed-time-day")),
+	}, nil
+}
+
+func (t *turtleSession) print() {
+	fmt.Printf("Turtle mode: %s\n", onOff(t.enabled))
+	fmt.Printf("Limits:      %s/s up, %s/s down\n",
+		units.HumanSize(float64(t.up*1000)),
+		units.HumanSize(float64(t.down*1000)))
+	fmt.Printf("Schedule:    %s (%s)\n", formatSchedule(t.days, t.begin, t.end), onOff(t.timeEnabled))
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+// setTurtle sets turtle mode to what next returns for its current state.
+func setTurtle(next func(on bool) bool) {
+	x := getRPC()
+	t, err := getTurtle(x)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	on := next(t.enabled)
+	if err = x.sessionSet(map[string]interface{}{"alt-speed-enabled": on}); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("Turtle mode: %s\n", onOff(on))
+}
+
+func doTurtleStatus(cmd *cobra.Command, args []string) {
+	t, err := getTurtle(getRPC())
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	t.print()
+}
+
+func doTurtleSchedule(cmd *cobra.Command, args []string) {
+	a := map[string]interface{}{}
+	switch {
+	case len(args) == 0:
+	case len(args) == 1 && (args[0] == "on" || args[0] == "off"):
+		a["alt-speed-time-enabled"] = args[0] == "on"
+	default:
+		days, begin, end, err := parseSchedule(strings.Join(args, " "))
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		a["alt-speed-time-enabled"] = true
+		a["alt-speed-time-day"] = days
+		a["alt-speed-time-begin"] = begin
+		a["alt-speed-time-end"] = end
+	}
+	for k, v := range map[string]string{"alt-speed-up": turtleUp, "alt-speed-down": turtleDown} {
+		if v == "" {
+			continue
+		}
+		r, err := parseRate(v)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		a[k] = r
+	}
+	x := getRPC()
+	if len(a) > 0 {
+		if err := x.sessionSet(a); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+	t, err := getTurtle(x)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	t.print()
+}
+
+// alt-speed-time-day bits
+const (
+	daySunday = 1 << iota
+	dayMonday
+	dayTuesday
+	dayWednesday
+	dayThursday
+	dayFriday
+	daySaturday
+
+	dayWeekdays = dayMonday | dayTuesday | dayWednesday | dayThursday | dayFriday
+	dayWeekends = daySunday | daySaturday
+	dayAll      = dayWeekdays | dayWeekends
+)
+
+var dayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// formatSchedule renders a schedule like "weekdays 09:00–18:00".
+func formatSchedule(days, begin, end int) string {
+	var d string
+	switch days & dayAll {
+	case dayAll:
+		d = "every day"
+	case dayWeekdays:
+		d = "weekdays"
+	case dayWeekends:
+		d = "weekends"
+	case 0:
+		d = "never"
+	default:
+		ds := []string{}
+		for i, n := range dayNames {
+			if days&(1<<uint(i)) != 0 {
+				ds = append(ds, n)
+			}
+		}
+		d = strings.Join(ds, ",")
+	}
+	return fmt.Sprintf("%s %02d:%02d–%02d:%02d", d, begin/60, begin%60, end/60, end%60)
+}
+
+// parseSchedule is the inverse of formatSchedule. It accepts - as well as –
+// between the times.
+func parseSchedule(s string) (days, begin, end int, err error) {
+	s = strings.TrimSpace(s)
+	i := strings.LastIndex(s, " ")
+	if i < 0 {
+		return 0, 0, 0, errors.New("want days followed by hh:mm-hh:mm")
+	}
+	if days, err = parseDays(strings.TrimSpace(s[:i])); err != nil {
+		return 0, 0, 0, err
+	}
+	times := strings.SplitN(strings.Replace(s[i+1:], "–", "-", 1), "-", 2)
+	if len(times) != 2 {
+		return 0, 0, 0, fmt.Errorf("%q: want hh:mm-hh:mm", s[i+1:])
+	}
+	if begin, err = parseClock(times[0]); err != nil {
+		return 0, 0, 0, err
+	}
+	if end, err = parseClock(times[1]); err != nil {
+		return 0, 0, 0, err
+	}
+	return days, begin, end, nil
+}
+
+func parseDays(s string) (int, error) {
+	switch strings.ToLower(s) {
+	case "every day", "daily", "all":
+		return dayAll, nil
+	case "weekdays":
+		return dayWeekdays, nil
+	case "weekends":
+		return dayWeekends, nil
+	}
+	days := 0
+	for _, r := range strings.Split(strings.ToLower(s), ",") {
+		ends := strings.SplitN(strings.TrimSpace(r), "-", 2)
+		from, err := parseDay(ends[0])
+		if err != nil {
+			return 0, err
+		}
+		to := from
+		if len(ends) == 2 {
+			if to, err = parseDay(ends[1]); err != nil {
+				return 0, err
+			}
+		}
+		for d := from; ; d = (d + 1) % len(dayNames) {
+			days |= 1 << uint(d)
+			if d == to {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+func parseDay(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if len(s) >= 3 {
+		for i, n := range dayNames {
+			if strings.HasPrefix(s, n) {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("%q is not a day", s)
+}
+
+// parseClock parses hh:mm into minutes after midnight.
+func parseClock(s string) (int, error) {
+	var h, m int
+	if _, err := fmt.Sscanf(strings.TrimSpace(s), "%d:%d", &h, &m); err != nil {
+		return 0, fmt.Errorf("%q: want hh:mm", s)
+	}
+	if h < 0 || h > 23 || m < 0 || m > 59 {
+		return 0, fmt.Errorf("%q is not a time of day", s)
+	}
+	return h*60 + m, nil
+}
+
+func init() {
+	RootCmd.AddCommand(turtleCmd)
+	turtleCmd.AddCommand(turtleOnCmd)
+	turtleCmd.AddCommand(turtleOffCmd)
+	turtleCmd.AddCommand(turtleToggleCmd)
+	turtleCmd.AddCommand(turtleStatusCmd)
+	turtleCmd.AddCommand(turtleScheduleCmd)
+
+	turtleScheduleCmd.Flags().StringVar(&turtleUp, "up", "", "alternate upload limit in kB/s, or with a unit, e.g. 100K")
+	turtleScheduleCmd.Flags().StringVar(&turtleDown, "down", "", "alternate download limit in kB/s, or with a unit, e.g. 1M")
+}
@@ -0,0 +1,158 @@
+// Copyright © 2017 Charles Haynes <ceh@ceh.bz>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	units "github.com/docker/go-units"
+	"github.com/spf13/cobra"
+)
+
+var torrentVerifyCmd = &cobra.Command{
+	Use:   "verify <file.torrent> <data-dir>",
+	Short: "Check local data against a .torrent file",
+	Long: `Hash the torrent's data as found in data-dir, the directory it would be
+downloaded into, and report how complete each file is and which pieces don't
+match. Use it to check moved or cross-seeded data before pointing the server at
+it.`,
+	Args: cobra.ExactArgs(2),
+	Run:  doTorrentVerify,
+}
+
+// verifyResult is what verifying a torrent's data found.
+type verifyResult struct {
+	good       []bool
+	mismatched []int
+	unreadable []int
+	fileDone   []int64
+}
+
+// verifyData hashes the data of m found in dir.
+func verifyData(m *metainfo, dir string, p *progress) *verifyResult {
+	files := make([]diskFile, len(m.files))
+	for i, f := range m.files {
+		files[i] = diskFile{
+			path:   filepath.Join(dir, filepath.FromSlash(f.path)),
+			length: f.length,
+			pad:    f.pad,
+		}
+	}
+	hs := hashPieces(files, m.pieceLength, p)
+	r := &verifyResult{good: make([]bool, len(hs)), fileDone: make([]int64, len(files))}
+	for i, h := range hs {
+		switch {
+		case h.err != nil:
+			r.unreadable = append(r.unreadable, i)
+		case (i+1)*sha1.Size <= len(m.pieces) && bytes.Equal(h.sum[:], m.pieces[i*sha1.Size:(i+1)*sha1.Size]):
+			r.good[i] = true
+		default:
+			r.mismatched = append(r.mismatched, i)
+		}
+	}
+	var start int64
+	for i, f := range files {
+		for off := start; off < start+f.length; {
+			piece := off / m.pieceLength
+			end := (piece + 1) * m.pieceLength
+			if end > start+f.length {
+				end = start + f.length
+			}
+			if r.good[piece] {
+				r.fileDone[i] += end - off
+			}
+			off = end
+		}
+		start += f.length
+	}
+	return r
+}
+
+// pieceRanges formats sorted piece numbers as ranges like 1-4,7.
+func pieceRanges(ps []int) string {
+	rs := []string{}
+	for i := 0; i < len(ps); {
+		j := i
+		for j+1 < len(ps) && ps[j+1] == ps[j]+1 {
+			j++
+		}
+		if i == j {
+			rs = append(rs, fmt.Sprint(ps[i]))
+		} else {
+			rs = append(rs, fmt.Sprintf("%d-%d", ps[i], ps[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(rs, ",")
+}
+
+func doTorrentVerify(cmd *cobra.Command, args []string) {
+	m, err := loadMetainfo(args[0])
+	if err != nil {
+		fmt.Printf("%s: %s\n", args[0], err)
+		return
+	}
+	if len(m.pieces) == 0 {
+		fmt.Printf("%s: only torrents with v1 piece hashes can be verified\n", args[0])
+		return
+	}
+	p := newProgress(m.totalSize())
+	r := verifyData(m, args[1], p)
+	p.finish()
+	good := 0
+	for _, g := range r.good {
+		if g {
+			good++
+		}
+	}
+	fmt.Printf("Torrent file: %s\n", args[0])
+	fmt.Printf("%s: %d of %d pieces good\n", m.name, good, len(r.good))
+	if len(r.mismatched) > 0 {
+		fmt.Printf("mismatched pieces: %s\n", pieceRanges(r.mismatched))
+	}
+	if len(r.unreadable) > 0 {
+		fmt.Printf("unreadable pieces: %s\n", pieceRanges(r.unreadable))
+	}
+	fmt.Println("  # Done    Size  Name")
+	rep := strings.NewReplacer(m.name, "@")
+	for i, f := range m.files {
+		if f.pad {
+			continue
+		}
+		done := 100.0
+		if f.length > 0 {
+			done = float64(r.fileDone[i]) / float64(f.length) * 100
+		}
+		fmt.Printf("%3d %3.0f%% %7s  %s\n",
+			i,
+			done,
+			units.HumanSize(float64(f.length)),
+			rep.Replace(f.path))
+	}
+}
+
+func init() {
+	torrentCmd.AddCommand(torrentVerifyCmd)
+}
@@ -0,0 +1,258 @@
+// Copyright © 2017 Charles Haynes <ceh@ceh.bz>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package bencode decodes the bencoding used by .torrent files.
+package bencode
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// maxDepth is how deeply lists and dictionaries may nest.
+const maxDepth = 64
+
+// Decode parses the single bencoded value that makes up data. Integers decode
+// to int64, byte strings to string, lists to []interface{} and dictionaries
+// to map[string]interface{}. Numbers have to be written canonically: no
+// leading zeros or plus signs, and no negative zero. Dictionary keys may come
+// in any order, as Transmission accepts, but not twice.
+func Decode(data []byte) (interface{}, error) {
+	d := decoder{data: data}
+	v, err := d.value()
+	if err != nil {
+		return nil, err
+	}
+	if d.pos != len(data) {
+		return nil, fmt.Errorf("bencode: trailing data at offset %d", d.pos)
+	}
+	return v, nil
+}
+
+// RawValue returns the encoded bytes of the value stored under key in the
+// dictionary that makes up data. The info hash of a torrent is the hash of
+// RawValue(data, "info"). The whole of data has to be valid, as for Decode.
+func RawValue(data []byte, key string) ([]byte, error) {
+	d := decoder{data: data}
+	if err := d.expect('d'); err != nil {
+		return nil, err
+	}
+	var raw []byte
+	seen := map[string]bool{}
+	for {
+		if d.pos >= len(d.data) {
+			return nil, errors.New("bencode: unterminated dictionary")
+		}
+		if d.data[d.pos] == 'e' {
+			break
+		}
+		k, err := d.key(seen)
+		if err != nil {
+			return nil, err
+		}
+		start := d.pos
+		if _, err := d.value(); err != nil {
+			return nil, err
+		}
+		if k == key {
+			raw = d.data[start:d.pos]
+		}
+	}
+	if d.pos+1 != len(d.data) {
+		return nil, fmt.Errorf("bencode: trailing data at offset %d", d.pos+1)
+	}
+	if raw == nil {
+		return nil, fmt.Errorf("bencode: no key %q", key)
+	}
+	return raw, nil
+}
+
+type decoder struct {
+	data  []byte
+	pos   int
+	depth int
+}
+
+func (d *decoder) expect(c byte) error {
+	if d.pos >= len(d.data) || d.data[d.pos] != c {
+		return fmt.Errorf("bencode: expected %q at offset %d", c, d.pos)
+	}
+	d.pos++
+	return nil
+}
+
+func (d *decoder) value() (interface{}, error) {
+	if d.pos >= len(d.data) {
+		return nil, errors.New("bencode: unexpected end of data")
+	}
+	switch c := d.data[d.pos]; {
+	case c == 'i':
+		return d.integer()
+	case c == 'l':
+		return d.list()
+	case c == 'd':
+		return d.dict()
+	case c >= '0' && c <= '9':
+		return d.str()
+	default:
+		return nil, fmt.Errorf("bencode: unexpected %q at offset %d", c, d.pos)
+	}
+}
+
+// until returns the text up to the next c, and moves past c.
+func (d *decoder) until(c byte) (string, error) {
+	for i := d.pos; i < len(d.data); i++ {
+		if d.data[i] == c {
+			s := string(d.data[d.pos:i])
+			d.pos = i + 1
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("bencode: expected %q after offset %d", c, d.pos)
+}
+
+func (d *decoder) integer() (int64, error) {
+	start := d.pos
+	d.pos++
+	s, err := d.until('e')
+	if err != nil {
+		return 0, err
+	}
+	if !canonical(s, true) {
+		return 0, fmt.Errorf("bencode: bad integer %q at offset %d", s, start)
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("bencode: bad integer at offset %d: %v", start, err)
+	}
+	return n, nil
+}
+
+// canonical reports whether s is a decimal integer with no sign, other than
+// a minus when negative is allowed, no leading zeros and no negative zero.
+func canonical(s string, negative bool) bool {
+	if negative && len(s) > 1 && s[0] == '-' {
+		s = s[1:]
+		if s[0] == '0' {
+			return false
+		}
+	}
+	if s == "" || (s[0] == '0' && len(s) > 1) {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *decoder) str() (string, error) {
+	start := d.pos
+	s, err := d.until(':')
+	if err != nil {
+		return "", err
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || !canonical(s, false) {
+		return "", fmt.Errorf("bencode: bad string length at offset %d", start)
+	}
+	if n > len(d.data)-d.pos {
+		return "", fmt.Errorf("bencode: string at offset %d runs past the end", start)
+	}
+	s = string(d.data[d.pos : d.pos+n])
+	d.pos += n
+	return s, nil
+}
+
+// nest enters a list or dictionary.
+func (d *decoder) nest() error {
+	d.depth++
+	if d.depth > maxDepth {
+		return fmt.Errorf("bencode: nested too deeply at offset %d", d.pos)
+	}
+	d.pos++
+	return nil
+}
+
+// key reads a dictionary key, which must not be in seen, and adds it.
+func (d *decoder) key(seen map[string]bool) (string, error) {
+	start := d.pos
+	k, err := d.str()
+	if err != nil {
+		return "", err
+	}
+	if seen[k] {
+		return "", fmt.Errorf("bencode: duplicate key %q at offset %d", k, start)
+	}
+	seen[k] = true
+	return k, nil
+}
+
+func (d *decoder) list() ([]interface{}, error) {
+	if err := d.nest(); err != nil {
+		return nil, err
+	}
+	l := []interface{}{}
+	for {
+		if d.pos >= len(d.data) {
+			return nil, errors.New("bencode: unterminated list")
+		}
+		if d.data[d.pos] == 'e' {
+			d.pos++
+			d.depth--
+			return l, nil
+		}
+		v, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		l = append(l, v)
+	}
+}
+
+func (d *decoder) dict() (map[string]interface{}, error) {
+	if err := d.nest(); err != nil {
+		return nil, err
+	}
+	m := map[string]interface{}{}
+	seen := map[string]bool{}
+	for {
+		if d.pos >= len(d.data) {
+			return nil, errors.New("bencode: unterminated dictionary")
+		}
+		if d.data[d.pos] == 'e' {
+			d.pos++
+			d.depth--
+			return m, nil
+		}
+		k, err := d.key(seen)
+		if err != nil {
+			return nil, err
+		}
+		v, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		m[k] = v
+	}
+}
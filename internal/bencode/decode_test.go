@@ -0,0 +1,142 @@
+// Copyright © 2017 Charles Haynes <ceh@ceh.bz>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package bencode
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	for _, c := range []struct {
+		in   string
+		want interface{}
+	}{
+		{"i0e", int64(0)},
+		{"i42e", int64(42)},
+		{"i-42e", int64(-42)},
+		{"0:", ""},
+		{"4:spam", "spam"},
+		{"le", []interface{}{}},
+		{"l4:spami1ee", []interface{}{"spam", int64(1)}},
+		{"de", map[string]interface{}{}},
+		{"d3:bar4:spam3:fooi42ee", map[string]interface{}{"bar": "spam", "foo": int64(42)}},
+		{"d3:fooi42e3:bar4:spame", map[string]interface{}{"bar": "spam", "foo": int64(42)}},
+		{"d1:ald1:bi1eeee", map[string]interface{}{"a": []interface{}{map[string]interface{}{"b": int64(1)}}}},
+	} {
+		got, err := Decode([]byte(c.in))
+		if err != nil {
+			t.Errorf("Decode(%q): %v", c.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Decode(%q) = %#v, want %#v", c.in, got, c.want)
+		}
+	}
+}
+
+func TestDecodeMalformed(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"i-0e",
+		"i03e",
+		"i+3e",
+		"ie",
+		"i-e",
+		"i12",
+		"i1x2e",
+		"i99999999999999999999e",
+		"03:abc",
+		"+3:abc",
+		"-1:",
+		"5:abc",
+		"3abc",
+		"l",
+		"li1e",
+		"d",
+		"d3:fooe",
+		"di1ei2ee",
+		"d3:foo1:a3:foo1:be",
+		"i1ei2e",
+		"x",
+		strings.Repeat("l", maxDepth+1) + strings.Repeat("e", maxDepth+1),
+	} {
+		if v, err := Decode([]byte(in)); err == nil {
+			t.Errorf("Decode(%q) = %#v, want an error", in, v)
+		}
+	}
+}
+
+func TestDecodeDepth(t *testing.T) {
+	in := strings.Repeat("l", maxDepth) + strings.Repeat("e", maxDepth)
+	if _, err := Decode([]byte(in)); err != nil {
+		t.Errorf("%d nested lists: %v", maxDepth, err)
+	}
+}
+
+func TestRawValueInfoHash(t *testing.T) {
+	info := "d6:lengthi12e4:name5:a.txt12:piece lengthi16384e6:pieces20:" + strings.Repeat("x", 20) + "e"
+	torrent := "d8:announce23:http://tracker/announce4:info" + info + "e"
+	raw, err := RawValue([]byte(torrent), "info")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(raw) != info {
+		t.Errorf("RawValue = %q, want %q", raw, info)
+	}
+	h := sha1.Sum(raw)
+	if got, want := hex.EncodeToString(h[:]), "d2e7fbfdd8894cd2e4d45e7a6faa836088c9a602"; got != want {
+		t.Errorf("info hash %s, want %s", got, want)
+	}
+}
+
+// Keys out of order are accepted, and the info hash is still over the bytes
+// as they are in the file.
+func TestRawValueUnsorted(t *testing.T) {
+	info := "d4:name5:a.txt6:lengthi12ee"
+	raw, err := RawValue([]byte("d4:info"+info+"8:announce1:ae"), "info")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := sha1.Sum(raw)
+	if got, want := hex.EncodeToString(h[:]), "f0fb0e3a123fde51f156833c186541c659c302aa"; got != want {
+		t.Errorf("info hash %s, want %s", got, want)
+	}
+}
+
+func TestRawValueErrors(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"le",
+		"d8:announce1:ae",
+		"d4:infoi1e",
+		"d4:info1:a4:info1:be",
+		"d4:infoi03ee",
+		"d4:infoi1eei2e",
+	} {
+		if _, err := RawValue([]byte(in), "info"); err == nil {
+			t.Errorf("RawValue(%q) succeeded, want an error", in)
+		}
+	}
+}
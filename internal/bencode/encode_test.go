@@ -0,0 +1,92 @@
+// Copyright © 2017 Charles Haynes <ceh@ceh.bz>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package bencode
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	for _, c := range []struct {
+		in   interface{}
+		want string
+	}{
+		{0, "i0e"},
+		{int64(-42), "i-42e"},
+		{"spam", "4:spam"},
+		{[]byte("ab"), "2:ab"},
+		{[]string{"a", "bc"}, "l1:a2:bce"},
+		{[]interface{}{1, "a"}, "li1e1:ae"},
+		{map[string]interface{}{"foo": 1, "bar": "x", "baz": []interface{}{}}, "d3:bar1:x3:bazle3:fooi1ee"},
+	} {
+		got, err := Encode(c.in)
+		if err != nil {
+			t.Errorf("Encode(%#v): %v", c.in, err)
+			continue
+		}
+		if string(got) != c.want {
+			t.Errorf("Encode(%#v) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestEncodeUnsupported(t *testing.T) {
+	for _, v := range []interface{}{1.5, nil, map[int]interface{}{1: 1}, []interface{}{true}} {
+		if _, err := Encode(v); err == nil {
+			t.Errorf("Encode(%#v) succeeded, want an error", v)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	v := map[string]interface{}{
+		"announce": "http://tracker/announce",
+		"info": map[string]interface{}{
+			"files": []interface{}{
+				map[string]interface{}{"length": int64(3), "path": []interface{}{"dir", "a"}},
+				map[string]interface{}{"length": int64(0), "path": []interface{}{"b"}},
+			},
+			"name":         "x",
+			"piece length": int64(16384),
+			"pieces":       "\x00\x01\xff",
+			"private":      int64(1),
+		},
+	}
+	b, err := Encode(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Decode(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, v) {
+		t.Errorf("round trip gave %#v, want %#v", got, v)
+	}
+	again, err := Encode(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != string(b) {
+		t.Errorf("re-encoding gave %q, want %q", again, b)
+	}
+}